@@ -0,0 +1,33 @@
+package main
+
+import (
+	. "modernc.org/tk9.0"
+	"modernc.org/tk9.0/b5"
+)
+
+func main() {
+	background := White
+	opts := Opts{Padx("1m"), Pady("2m")}
+	Grid(TButton(Txt("Small"), Style(b5.SizedButtonStyle("sm.primary.TButton", b5.PrimaryColors, background, b5.Small))),
+		TButton(Txt("Medium"), Style(b5.SizedButtonStyle("md.primary.TButton", b5.PrimaryColors, background, b5.Medium))),
+		TButton(Txt("Large"), Style(b5.SizedButtonStyle("lg.primary.TButton", b5.PrimaryColors, background, b5.Large))),
+		opts)
+	Grid(TButton(Txt("Outline"), Style(b5.OutlineButtonStyle("outline.success.TButton", b5.SuccessColors, background, b5.Medium))),
+		TButton(Txt("Outline"), Style(b5.OutlineButtonStyle("outline.danger.TButton", b5.DangerColors, background, b5.Medium))),
+		TLabel(Txt("New"), Style(b5.BadgeStyle("info.Badge.TLabel", b5.InfoColors, background))),
+		opts)
+	Grid(TEntry(Style(b5.EntryStyle("b5.TEntry", b5.PrimaryColors, background)), Textvariable("Entry")),
+		TCombobox(Style(b5.ComboboxStyle("b5.TCombobox", b5.PrimaryColors, background)), Values("One Two Three")),
+		TCheckbutton(Txt("Switch"), Style(b5.SwitchStyle("b5.Switch.TCheckbutton", b5.PrimaryColors, background))),
+		opts)
+	pb := TProgressbar(Style(b5.ProgressbarStyle("striped.Horizontal.TProgressbar", b5.SuccessColors, background, true)), Value(60))
+	Grid(pb, Columnspan(3), Sticky("we"), opts)
+	Grid(b5.Alert(nil, b5.AlertStyle("warning.Alert", b5.WarningColors, background), "A simple warning alert."),
+		Columnspan(3), Sticky("we"), opts)
+	card, body := b5.Card(nil, b5.CardStyle("b5.Card", b5.LightColors, background), "Card title")
+	Pack(body.TLabel(Txt("Some quick example text."), Background(White)))
+	group, _ := b5.ListGroup(nil, b5.ListGroupStyle("b5.ListGroup", b5.PrimaryColors, background), Variable("Two"), "One", "Two", "Three")
+	Grid(card, group, Sticky("nswe"), opts)
+	Grid(TExit(), Columnspan(3), opts)
+	App.Configure(Background(background)).Wait()
+}
@@ -0,0 +1,59 @@
+// editor demo
+package main
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	. "modernc.org/tk9.0"
+	. "modernc.org/tk9.0/extensions/autoscroll"
+	_ "modernc.org/tk9.0/extensions/ctext"
+	. "modernc.org/tk9.0/extensions/editor"
+)
+
+func main() {
+	InitializeExtension("autoscroll")
+	InitializeExtension("ctext")
+	InitializeExtension("editor")
+	var yscroll *Window
+	t := Editor(Font(FixedFont), Width(100), Height(40), Undo(true),
+		Yscrollcommand(func(e *Event) { e.ScrollSet(yscroll) }))
+	yscroll = Autoscroll(TScrollbar(Command(func(e *Event) { e.Yview(t) })).Window)
+	t.SetLanguage("Go")
+	b, _ := os.ReadFile("editor.go")
+	t.Insert("1.0", string(b))
+	words := map[string]bool{}
+	t.SetCompletionProvider(CompletionFunc(func(req CompletionRequest) (r []Completion) {
+		for _, v := range strings.FieldsFunc(req.Text, func(c rune) bool {
+			return !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9')
+		}) {
+			words[v] = true
+		}
+		for k := range words {
+			if k != req.Prefix && strings.HasPrefix(k, req.Prefix) {
+				r = append(r, Completion{Label: k})
+			}
+		}
+		sort.Slice(r, func(i, j int) bool { return r[i].Label < r[j].Label })
+		return r
+	}), 3)
+	breakpoints := map[int]MarkerID{}
+	t.OnGutterClick(func(line int) {
+		if id, ok := breakpoints[line]; ok {
+			t.RemoveMarker(id)
+			delete(breakpoints, line)
+			return
+		}
+
+		breakpoints[line] = t.AddMarker(line, "breakpoint", "breakpoint")
+	})
+	t.AddMarker(17, "info", "InitializeExtension must be called from main")
+	t.ToggleFold(22)
+	Grid(t, Sticky(NEWS))
+	Grid(yscroll, Row(0), Column(1), Sticky(NS))
+	GridRowConfigure(App, 0, Weight(1))
+	GridColumnConfigure(App, 0, Weight(1))
+	Grid(TExit(), Columnspan(2))
+	App.Wait()
+}
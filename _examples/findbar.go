@@ -0,0 +1,22 @@
+// Find and replace bar demo
+package main
+
+import (
+	"os"
+
+	. "modernc.org/tk9.0"
+)
+
+func main() {
+	t := Text(Width(80), Height(25), Undo(true))
+	b, _ := os.ReadFile("findbar.go")
+	t.Insert(END, string(b))
+	bar := FindBar(t)
+	Grid(bar, Sticky(WE))
+	Grid(t, Sticky(NEWS))
+	GridRowConfigure(App, 1, Weight(1))
+	GridColumnConfigure(App, 0, Weight(1))
+	Grid(TExit(), Pady("1m"))
+	bar.Focus()
+	App.Wait()
+}
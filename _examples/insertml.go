@@ -0,0 +1,41 @@
+package main
+
+import "fmt"
+import . "modernc.org/tk9.0"
+import _ "modernc.org/tk9.0/themes/azure"
+
+func main() {
+	fontSize := int(10*TkScaling()/NativeScaling + 0.5)
+	var scroll *TScrollbarWidget
+	t := Text(Font("helvetica", fontSize), Height(24), Yscrollcommand(func(e *Event) { e.ScrollSet(scroll) }), Setgrid(true),
+		Wrap("word"), Padx("4p"), Pady("12p"))
+	scroll = TScrollbar(Command(func(e *Event) { e.Yview(t) }))
+	Grid(t, Sticky("news"), Pady("2m"))
+	Grid(scroll, Row(0), Column(1), Sticky("nes"), Pady("2m"))
+	GridRowConfigure(App, 0, Weight(1))
+	GridColumnConfigure(App, 0, Weight(1))
+	Grid(TExit())
+	t.SetLinkHandler(func(href string) { fmt.Println(href) })
+	t.InsertML(`<h1>InsertML</h1>
+A mini HTML viewer supporting <b>bold</b>, <i>italic</i>, <u>underline</u>, <code>code</code> and
+<span style="color: #c00000; font-weight: bold">inline styles</span>.
+<h2>Lists</h2>
+<ul>
+	<li>Visit <a href="https://pkg.go.dev/modernc.org/tk9.0">the documentation</a></li>
+	<li>Nested lists
+		<ol>
+			<li>first</li>
+			<li>second</li>
+		</ol>
+	</li>
+</ul>
+<h2>Tables</h2>
+<table>
+	<tr><th>Tag</th><th align="center">Supported</th></tr>
+	<tr><td>&lt;table&gt;</td><td align="center">yes</td></tr>
+	<tr><td>&lt;a&gt;</td><td align="center">yes</td></tr>
+</table>
+Math works too: $e^{i\pi} + 1 = 0$`)
+	ActivateTheme("azure light")
+	App.Center().Wait()
+}
@@ -0,0 +1,49 @@
+package main
+
+import "fmt"
+import . "modernc.org/tk9.0"
+import _ "embed"
+import _ "modernc.org/tk9.0/themes/azure"
+
+//go:embed gotk.png
+var icon []byte
+
+func main() {
+	fontSize := int(10*TkScaling()/NativeScaling + 0.5)
+	var scroll *TScrollbarWidget
+	t := Text(Font("helvetica", fontSize), Height(30), Yscrollcommand(func(e *Event) { e.ScrollSet(scroll) }), Setgrid(true),
+		Wrap("word"), Padx("4p"), Pady("12p"))
+	scroll = TScrollbar(Command(func(e *Event) { e.Yview(t) }))
+	Grid(t, Sticky("news"), Pady("2m"))
+	Grid(scroll, Row(0), Column(1), Sticky("nes"), Pady("2m"))
+	GridRowConfigure(App, 0, Weight(1))
+	GridColumnConfigure(App, 0, Weight(1))
+	Grid(TExit())
+	t.SetLinkHandler(func(href string) { fmt.Println(href) })
+	t.InsertMarkdown(fmt.Sprintf(`# Release notes ![logo](%s)
+
+Rendered by **InsertMarkdown**, with *emphasis*, ~~strikethrough~~ and `+"`inline code`"+`.
+
+## Changes
+
+1. Markdown support
+   - headings, lists and [links](https://pkg.go.dev/modernc.org/tk9.0)
+   - tables and images
+2. Bug fixes
+
+> Block quotes work as well.
+
+`+"```"+`go
+t.InsertMarkdown(src)
+`+"```"+`
+
+| Feature | Status |
+|:--------|:------:|
+| Lists   | done   |
+| Tables  | done   |
+
+---
+See https://gitlab.com/cznic/tk9.0 for more.`, NewPhoto(Data(icon))))
+	ActivateTheme("azure light")
+	App.Center().Wait()
+}
@@ -0,0 +1,46 @@
+package main
+
+import . "modernc.org/tk9.0"
+
+func main() {
+	for _, v := range []struct {
+		name, bg, fg, accent string
+		dark                 bool
+	}{
+		{"ocean light", "#eef4f9", "#1b2733", "#0b5394", false},
+		{"ocean dark", "#1b2733", "#e6edf3", "#6fa8dc", true},
+	} {
+		RegisterThemeSpec(&ThemeSpec{
+			Name:    v.name,
+			Variant: &ThemeVariant{Family: "Ocean", Dark: v.dark},
+			Palette: map[string]string{"bg": v.bg, "fg": v.fg, "accent": v.accent},
+			Fonts:   map[string]ThemeFont{"ui": {Family: "Helvetica", Size: 11}},
+			Elements: map[string]ElementSpec{
+				"Ocean.TButton.border": {
+					Image: ImageSpec{SVG: `<svg width="24" height="24"><rect width="24" height="24" rx="6" fill="` + v.accent + `"/></svg>`},
+					States: []StateImage{
+						{State: "pressed", ImageSpec: ImageSpec{SVG: `<svg width="24" height="24"><rect width="24" height="24" rx="6" fill="` + v.fg + `"/></svg>`}},
+					},
+					Border: "8",
+					Sticky: "nsew",
+				},
+			},
+			Styles: map[string]StyleSpec{
+				".": {Configure: map[string]string{"background": "$bg", "foreground": "$fg", "font": "$ui"}},
+				"TButton": {
+					Configure: map[string]string{"padding": "8 4"},
+					Map:       map[string][]StateValue{"foreground": {{State: "disabled", Value: "gray"}}},
+				},
+				"Ocean.TButton": {
+					Layout:    "Ocean.TButton.border -sticky nsew -children {Button.padding -sticky nsew -children {Button.label -sticky nsew}}",
+					Configure: map[string]string{"foreground": "$bg", "padding": "12 6"},
+				},
+			},
+		})
+	}
+	ActivateThemeAuto("ocean light", "ocean dark")
+	Pack(TLabel(Txt("A theme declared in Go")), Pady("2m"))
+	Pack(TButton(Txt("Toggle dark mode"), Style("Ocean.TButton"), Command(func() { ActivateThemeVariant(!IsDarkTheme()) })), Pady("2m"))
+	Pack(TExit(), Pady("2m"))
+	App.Wait()
+}
@@ -0,0 +1,25 @@
+// Undo manager demo
+package main
+
+import . "modernc.org/tk9.0"
+
+func main() {
+	t := Text(Width(60), Height(15), Wrap("word"))
+	m := NewUndoManager(t)
+	undo := TButton(Txt("Undo"), Command(func() { m.Undo() }))
+	redo := TButton(Txt("Redo"), Command(func() { m.Redo() }))
+	update := func() {
+		undo.Configure(State(map[bool]string{true: "normal", false: "disabled"}[m.CanUndo()]))
+		redo.Configure(State(map[bool]string{true: "normal", false: "disabled"}[m.CanRedo()]))
+	}
+	m.OnChange(update)
+	update()
+	m.BeginGroup()
+	t.Insert(END, "Type here, then undo and redo.\n")
+	t.Insert(END, "Typing runs are undone as a whole.\n")
+	m.EndGroup()
+	Grid(undo, redo, Padx("1m"), Pady("1m"))
+	Grid(t, Columnspan(2), Padx("1m"), Pady("1m"))
+	Grid(TExit(), Columnspan(2), Pady("1m"))
+	App.Wait()
+}
@@ -0,0 +1,43 @@
+// Entry validation demo
+package main
+
+import (
+	"fmt"
+	"regexp"
+
+	. "modernc.org/tk9.0"
+)
+
+func main() {
+	StyleMap("TEntry", Fieldbackground, "invalid", "#ffd0d0")
+	row := 0
+	for _, v := range []struct {
+		label     string
+		validator Validator
+	}{
+		{"Age (0-150)", IntegerRange(0, 150)},
+		{"Price", Float()},
+		{"Identifier", MatchRegexp(regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$|^$`))},
+		{"Code (max 5)", MaxLength(5)},
+		{"Date (YYYY-MM-DD)", Date("2006-01-02")},
+		{"Phone", Mask(PhoneMask)},
+		{"IP address", IPAddress()},
+	} {
+		Grid(TLabel(Txt(v.label)), Row(row), Column(0), Sticky(E), Padx("1m"), Pady("1m"))
+		Grid(TEntry(Validate(v.validator)), Row(row), Column(1), Sticky(WE), Padx("1m"), Pady("1m"))
+		row++
+	}
+	status := TLabel()
+	Grid(TLabel(Txt("Even number")), Row(row), Column(0), Sticky(E), Padx("1m"), Pady("1m"))
+	Grid(TEntry(Validate(func(v ValidationEvent) bool {
+		n := 0
+		_, err := fmt.Sscan(v.Proposed, &n)
+		ok := v.Proposed == "" || err == nil && n%2 == 0
+		status.Configure(Txt(fmt.Sprintf("%s %q -> %q: %v", v.Reason, v.Prior, v.Proposed, ok)))
+		return ok || v.Partial() && err == nil
+	})), Row(row), Column(1), Sticky(WE), Padx("1m"), Pady("1m"))
+	Grid(status, Row(row+1), Columnspan(2))
+	Grid(TExit(), Row(row+2), Columnspan(2), Pady("1m"))
+	GridColumnConfigure(App, 1, Weight(1))
+	App.Wait()
+}
@@ -7,20 +7,16 @@ import (
 	"unicode"
 
 	. "modernc.org/tk9.0"
-	_ "modernc.org/tk9.0/extensions/eval"
 	_ "modernc.org/tk9.0/themes/azure"
 )
 
 func main() {
 	ActivateTheme("azure light")
-	// Proxying widgets requires that the eval extension be enabled.
-	InitializeExtension("eval")
-
 	var text TextWidgetProxy
 
 	// textInsert will be called when text is about to be inserted.
 	// The text might have been typed or pasted.
-	textInsert := func(args []string) {
+	textInsert := func(args []string) (string, error) {
 		fmt.Println("Insert args", args)
 
 		newText := args[2]
@@ -29,18 +25,18 @@ func main() {
 		// Ignore text input that starts with a digit.
 		if unicode.IsDigit(rune(newText[0])) {
 			fmt.Println("  starts with a digit, so not inserted")
-			return
+			return "", nil
 		}
 
 		// Transform new text to uppercase, and then insert it.
 		newText = strings.ToUpper(newText)
 		args[2] = newText
-		text.EvalWrapped(args)
+		return text.EvalWrapped(args)
 	}
 
 	// textDelete will be called when text is about to be deleted.
 	// It may be a single character, or it may be selected text.
-	textDelete := func(args []string) {
+	textDelete := func(args []string) (string, error) {
 		fmt.Println("Delete args :", args)
 
 		index1 := args[1]
@@ -52,11 +48,12 @@ func main() {
 		if err != nil {
 			fmt.Println(err)
 		}
-		if count < 5 {
-			text.EvalWrapped(args)
-		} else {
+		if count >= 5 {
 			fmt.Println("  too long to delete")
+			return "", nil
 		}
+
+		return text.EvalWrapped(args)
 	}
 
 	label := Label(Txt(`Try entering and deleting text. Try cutting and pasting.
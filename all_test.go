@@ -7,8 +7,13 @@ package tk9_0 // import "modernc.org/tk9.0"
 import (
 	"bytes"
 	_ "embed"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -310,3 +315,887 @@ func testExample(t *testing.T, wd, tmpDir, bin string) (err error) {
 
 	return nil
 }
+
+func mdDumpInlines(nodes []*mdInline) string {
+	var a []string
+	for _, v := range nodes {
+		switch v.kind {
+		case mdText:
+			a = append(a, fmt.Sprintf("%q", v.text))
+		case mdSoftBreak:
+			a = append(a, "sb")
+		case mdHardBreak:
+			a = append(a, "br")
+		case mdCodeSpan:
+			a = append(a, fmt.Sprintf("code(%q)", v.text))
+		case mdEmph:
+			a = append(a, fmt.Sprintf("em(%s)", mdDumpInlines(v.children)))
+		case mdStrong:
+			a = append(a, fmt.Sprintf("strong(%s)", mdDumpInlines(v.children)))
+		case mdDel:
+			a = append(a, fmt.Sprintf("del(%s)", mdDumpInlines(v.children)))
+		case mdLink:
+			a = append(a, fmt.Sprintf("a[%s](%s)", v.href, mdDumpInlines(v.children)))
+		case mdImage:
+			a = append(a, fmt.Sprintf("img[%s](%s)", v.href, mdDumpInlines(v.children)))
+		}
+	}
+	return strings.Join(a, " ")
+}
+
+func mdDumpBlocks(blocks []*mdBlock) string {
+	var a []string
+	for _, v := range blocks {
+		switch v.kind {
+		case mdParagraph:
+			a = append(a, fmt.Sprintf("p%q", v.lines))
+		case mdHeading:
+			a = append(a, fmt.Sprintf("h%d%q", v.level, v.lines))
+		case mdCode:
+			a = append(a, fmt.Sprintf("code%q", v.lines))
+		case mdQuote:
+			a = append(a, fmt.Sprintf("quote(%s)", mdDumpBlocks(v.children)))
+		case mdList:
+			a = append(a, fmt.Sprintf("list(ordered=%v start=%v tight=%v %s)", v.ordered, v.start, v.tight, mdDumpBlocks(v.children)))
+		case mdItem:
+			a = append(a, fmt.Sprintf("li(%s)", mdDumpBlocks(v.children)))
+		case mdRule:
+			a = append(a, "hr")
+		case mdTable:
+			a = append(a, fmt.Sprintf("table%q%q", v.align, v.rows))
+		}
+	}
+	return strings.Join(a, " ")
+}
+
+func TestMarkdownBlocks(t *testing.T) {
+	for i, test := range []struct {
+		src string
+		exp string
+	}{
+		{"", ""},
+		{"a\nb\n\nc", `p["a" "b"] p["c"]`},
+		{"# a #\n## b\n#c", `h1["a"] h2["b"] p["#c"]`},
+		{"a\n===\nb\n---", `h1["a"] h2["b"]`},
+		{"***\n- - -", `hr hr`},
+		{"    x\n\n    y", `code["x" "" "y"]`},
+		{"```go\nx\n  y\n```\nz", `code["x" "  y"] p["z"]`},
+		{"> a\nb\n> c", `quote(p["a" "b" "c"])`},
+		{"- a\n- b\n\n* c", `list(ordered=false start=0 tight=true li(p["a"]) li(p["b"])) list(ordered=false start=0 tight=true li(p["c"]))`},
+		{"3. a\n\n4. b", `list(ordered=true start=3 tight=false li(p["a"]) li(p["b"]))`},
+		{"- a\n  - b\n  - c\n- d", `list(ordered=false start=0 tight=true li(p["a"] list(ordered=false start=0 tight=true li(p["b"]) li(p["c"]))) li(p["d"]))`},
+		{"| a | b |\n|:--|--:|\n| 1 | 2 |\n| 3 |", `table["left" "right"][["a" "b"] ["1" "2"] ["3" ""]]`},
+		{"[x]: /url \"t\"\n[x]", `p["[x]"]`},
+		{"[0]:<", `p["[0]:<"]`},
+		{"[0]:<a", `p["[0]:<a"]`},
+		{"[0]:<a>\n[0]", `p["[0]"]`},
+	} {
+		if g, e := mdDumpBlocks(parseMarkdown(test.src).blocks), test.exp; g != e {
+			t.Errorf("#%3v: %q\ngot %s\nexp %s", i, test.src, g, e)
+		}
+	}
+}
+
+func TestMarkdownInlines(t *testing.T) {
+	doc := parseMarkdown("[ref]: http://example.com")
+	for i, test := range []struct {
+		src string
+		exp string
+	}{
+		{"a", `"a"`},
+		{"*a* **b** ***c***", `em("a") " " strong("b") " " em(strong("c"))`},
+		{"_a_b_ foo_bar_", `em("a" "_" "b") " foo" "_" "bar" "_"`},
+		{"**a *b* c**", `strong("a " em("b") " c")`},
+		{"~~a~~", `del("a")`},
+		{"`a*b*` ``c`d``", `code("a*b*") " " code("c` + "`" + `d")`},
+		{`\*a\*`, `"*a*"`},
+		{"a  \nb\nc", `"a" br "b" sb "c"`},
+		{"[a *b*](/u \"t\")", `a[/u]("a " em("b"))`},
+		{"![alt](img1)", `img[img1]("alt")`},
+		{"[ref] [x][ref] [Ref][]", `a[http://example.com]("ref") " " a[http://example.com]("x") " " a[http://example.com]("Ref")`},
+		{"<http://a.b> <me@x.org>", `a[http://a.b]("http://a.b") " " a[mailto:me@x.org]("me@x.org")`},
+		{"see https://x.org/a.", `"see " a[https://x.org/a]("https://x.org/a") "."`},
+		{"[a](b", `"[" "a" "]" "(b"`},
+		{"&amp; &copy;", `"& ©"`},
+	} {
+		if g, e := mdDumpInlines(doc.inlines(test.src)), test.exp; g != e {
+			t.Errorf("#%3v: %q\ngot %s\nexp %s", i, test.src, g, e)
+		}
+	}
+}
+
+// needTk skips the test if Tk cannot be initialized, for example when there
+// is no display.
+func needTk(t *testing.T) {
+	t.Helper()
+	if _, err := eval("winfo exists ."); err != nil {
+		t.Skip(err)
+	}
+}
+
+func TestMarkdownRenderer(t *testing.T) {
+	needTk(t)
+	w := Text()
+	defer Destroy(w)
+	w.SetLinkHandler(func(string) {})
+	w.InsertMarkdown("# Title\n\nSome **bold** and [a link](http://example.com).")
+	if g, e := w.Text(), "Title\n\nSome bold and a link."; g != e {
+		t.Fatalf("got %q, expected %q", g, e)
+	}
+
+	for _, v := range []struct{ tag, exp string }{
+		{"h1", "1.0 1.5"},
+		{"b", "3.5 3.9"},
+		{"a", "3.14 3.20"},
+	} {
+		if g, e := strings.Join(w.TagRanges(v.tag), " "), v.exp; g != e {
+			t.Errorf("tag %s: got %q, expected %q", v.tag, g, e)
+		}
+	}
+
+	var link string
+	for _, v := range w.TagNames("3.15") {
+		if strings.HasPrefix(v, "link") {
+			link = v
+		}
+	}
+	if link == "" {
+		t.Fatal("link tag not found")
+	}
+
+	cursor := w.Cursor()
+	if g := evalErr(fmt.Sprintf("%s tag bind %s <Leave>", w, link)); !strings.HasSuffix(g, " -cursor "+cursor) {
+		t.Errorf("<Leave> binding %q does not restore the cursor %q", g, cursor)
+	}
+
+	Destroy(w)
+	if _, ok := linkHandlers[w.Window]; ok {
+		t.Error("link handler not removed on destroy")
+	}
+}
+
+func TestMarkdownImages(t *testing.T) {
+	needTk(t)
+	dir := t.TempDir()
+	good, bad := filepath.Join(dir, "good.png"), filepath.Join(dir, "bad.png")
+	var b bytes.Buffer
+	if err := png.Encode(&b, image.NewGray(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(good, b.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(bad, []byte("not a png"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	w := Text()
+	defer Destroy(w)
+	n := len(parseList(evalErr("image names")))
+	src := fmt.Sprintf("![a](%s) ![b](%s) ![c](%s) ![d](missing.png)", good, bad, good)
+	w.InsertMarkdown(src)
+	w.InsertMarkdown(src)
+	if g, e := w.Text(), " b  d b  d"; g != e {
+		t.Errorf("got %q, expected %q", g, e)
+	}
+
+	if g, e := len(parseList(evalErr("image names"))), n+1; g != e {
+		t.Errorf("got %v images, expected %v", g, e)
+	}
+
+	Destroy(w)
+	if g, e := len(parseList(evalErr("image names"))), n; g != e {
+		t.Errorf("got %v images after destroy, expected %v", g, e)
+	}
+}
+
+func TestInsertML(t *testing.T) {
+	needTk(t)
+	w := Text()
+	defer Destroy(w)
+	w.InsertML(`<h1>T</h1><ul><li>one</li><li>two</li></ul><span style="color: red">r</span><b><i>z</i></b>`)
+	if g, e := w.Text(), "T\n• one\n• two\nrz"; g != e {
+		t.Fatalf("got %q, expected %q", g, e)
+	}
+
+	for _, v := range []struct{ tag, exp string }{
+		{"h1", "1.0 1.1"},
+		{"li1", "2.0 2.5 3.0 3.5"},
+		{"style{color:red}", "4.0 4.1"},
+		{"bi", "4.1 4.2"},
+	} {
+		if g, e := strings.Join(w.TagRanges(v.tag), " "), v.exp; g != e {
+			t.Errorf("tag %s: got %q, expected %q", v.tag, g, e)
+		}
+	}
+}
+
+func TestInsertMLAt(t *testing.T) {
+	needTk(t)
+	w := Text()
+	defer Destroy(w)
+	w.Insert("end", "ab")
+	start, end := w.InsertMLAt("1.1", "<b>X</b>y")
+	if g, e := fmt.Sprintf("%s %s %q", start, end, w.Text()), `1.1 1.3 "aXyb"`; g != e {
+		t.Errorf("got %s, expected %s", g, e)
+	}
+
+	if g, e := strings.Join(w.TagRanges("b"), " "), "1.1 1.2"; g != e {
+		t.Errorf("tag b: got %q, expected %q", g, e)
+	}
+
+	func() {
+		defer func() { recover() }()
+		w.InsertMLAt("end", `<img src="tk9nosuchimage">`)
+	}()
+	for _, v := range w.MarkNames() {
+		if strings.HasPrefix(v, "tk9") {
+			t.Errorf("leaked mark %s", v)
+		}
+	}
+}
+
+func TestTeXCache(t *testing.T) {
+	c := texCache
+	texCache = newTeXCache(2)
+	defer func() { texCache = c }()
+
+	texCache.dir = t.TempDir()
+	b, err := TeX2("$x^2$", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := texKey("$x^2$", 1, &texConfig)
+	if _, ok := texCache.items[key]; !ok {
+		t.Fatal("not cached in memory")
+	}
+
+	if b2, err := os.ReadFile(filepath.Join(texCache.dir, key+".png")); err != nil || !bytes.Equal(b, b2) {
+		t.Fatalf("not persisted: %v", err)
+	}
+
+	// Served from disk after eviction from memory.
+	texCache.put(texKey("a", 1, &texConfig), nil)
+	texCache.put(texKey("b", 1, &texConfig), nil)
+	if _, ok := texCache.items[key]; ok {
+		t.Fatal("not evicted")
+	}
+
+	if b2, ok := texCache.get(key); !ok || !bytes.Equal(b, b2) {
+		t.Fatal("not loaded from disk")
+	}
+
+	// Callers own the returned bytes.
+	b2, _ := texCache.get(key)
+	b2[0]++
+	if b3, _ := texCache.get(key); !bytes.Equal(b, b3) {
+		t.Fatal("cache modified through a returned slice")
+	}
+
+	SetTeXCacheSize(0)
+	if g := texCache.lru.Len(); g != 0 {
+		t.Fatalf("cache size %v after SetTeXCacheSize(0)", g)
+	}
+}
+
+func TestTeXConfig(t *testing.T) {
+	defer SetTeXConfig(TeXConfig{})
+
+	SetTeXConfig(TeXConfig{LaTeXMacros: true})
+	for i, src := range []string{
+		`$\frac{a}{b} + \mathbb{R} + \operatorname{sin} x + \text{if } y$`,
+		`$$\begin{aligned} a &= b + c \\ d &= \dfrac{e}{f} \end{aligned}$$`,
+		`$f(x) = \begin{cases} 0 & x < 0 \\ 1 & \text{otherwise} \end{cases}$`,
+		`$\textcolor{red}{x} + {\color{blue} y}$`,
+	} {
+		if _, err := TeXImg2(src, 1); err != nil {
+			t.Errorf("#%v: %v", i, err)
+		}
+	}
+
+	fg, bg := color.RGBA{0, 255, 0, 255}, color.RGBA{0, 0, 64, 255}
+	SetTeXConfig(TeXConfig{Foreground: fg, Background: bg})
+	img, err := TeXImg2(`$\special{color push rgb 1 0 0}x\special{color pop} \vrule width 10pt height 10pt$`, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	colors := map[color.RGBA]bool{}
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			colors[color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)] = true
+		}
+	}
+	for _, v := range []color.RGBA{fg, bg, {255, 0, 0, 255}} {
+		if !colors[v] {
+			t.Errorf("missing color %v", v)
+		}
+	}
+
+	if _, err := TeXImg2(`$\special{color push nosuchcolor}x$`, 1); err == nil {
+		t.Error("unexpected success")
+	}
+}
+
+type testTheme struct{ activated *[]string }
+
+func (t testTheme) Activate(context ThemeContext) error {
+	*t.activated = append(*t.activated, "on")
+	return nil
+}
+
+func (t testTheme) Deactivate(context ThemeContext) error {
+	*t.activated = append(*t.activated, "off")
+	return nil
+}
+
+func (t testTheme) Finalize(context ThemeContext) error { return nil }
+
+func (t testTheme) Initialize(context ThemeContext) error {
+	*t.activated = append(*t.activated, "init")
+	return nil
+}
+
+func TestThemeRegistry(t *testing.T) {
+	var log []string
+	light, err := RegisterTheme("test light", testTheme{&log})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dark, err := RegisterTheme("test dark", &testTheme{&log})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		Themes[light].Finalize(nil)
+		Themes[dark].Finalize(nil)
+	}()
+
+	if err := SetThemeVariant(light, ThemeVariant{Family: "test"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SetThemeVariant(dark, ThemeVariant{Family: "test", Dark: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SetThemeVariant(ThemeKey{Name: "no such theme"}, ThemeVariant{}); err != NotFound {
+		t.Fatalf("got %v, expected %v", err, NotFound)
+	}
+
+	if v, ok := ThemeVariantOf(dark); !ok || v.Family != "test" || !v.Dark {
+		t.Fatalf("got %+v %v", v, ok)
+	}
+
+	var changes []string
+	OnThemeChanged(func(old, new ThemeKey) { changes = append(changes, old.Name+"->"+new.Name) })
+	defer func() { themeChangedHandlers = nil }()
+
+	if err := Themes[light].Activate(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if IsDarkTheme() {
+		t.Fatal("expected light theme")
+	}
+
+	if err := Themes[dark].Activate(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if !IsDarkTheme() {
+		t.Fatal("expected dark theme")
+	}
+
+	// Activating a theme again does not initialize it again.
+	if err := Themes[light].Activate(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if g, e := strings.Join(log, " "), "init on off init on off on"; g != e {
+		t.Errorf("activation log: got %q, expected %q", g, e)
+	}
+
+	if g, e := strings.Join(changes, " "), "->test light test light->test dark test dark->test light"; g != e {
+		t.Errorf("changes: got %q, expected %q", g, e)
+	}
+}
+
+func TestStyleThemeUseDeactivates(t *testing.T) {
+	needTk(t)
+	var log []string
+	k, err := RegisterTheme("test deactivate", testTheme{&log})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer Themes[k].Finalize(nil)
+
+	if err := Themes[k].Activate(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	evalErr("ttk::style theme create tk9test -parent default")
+	StyleThemeUse("tk9test")
+	if g, e := strings.Join(log, " "), "init on off"; g != e {
+		t.Errorf("activation log: got %q, expected %q", g, e)
+	}
+
+	if g, e := CurrentThemeName(), "tk9test"; g != e {
+		t.Errorf("current theme: got %q, expected %q", g, e)
+	}
+}
+
+func TestDetectDarkMode(t *testing.T) {
+	if goos == "windows" {
+		t.Skip("needs a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	// gdbus stand-in answering the portal Settings.Read call.
+	gdbus := filepath.Join(dir, "gdbus")
+	fakeGdbus := func(scheme int) {
+		if err := os.WriteFile(gdbus, []byte(fmt.Sprintf("#!/bin/sh\necho '(<<uint32 %d>>,)'\n", scheme)), 0o700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config := filepath.Join(dir, "config")
+	if err := os.MkdirAll(filepath.Join(config, "gtk-3.0"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(config, "gtk-3.0", "settings.ini"), []byte("[Settings]\ngtk-application-prefer-dark-theme=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, v := range []struct {
+		scheme   int // < 0: no gdbus
+		config   string
+		gtkTheme string
+		dark, ok bool
+	}{
+		{colorSchemeDark, "", "", true, true},
+		{colorSchemeLight, config, "", false, true},
+		{colorSchemeDefault, config, "", true, true},
+		{colorSchemeDefault, "", "", false, false},
+		{-1, "", "Adwaita:dark", true, true},
+		{-1, config, "", true, true},
+		{-1, dir, "", false, false},
+	} {
+		bin := ""
+		if v.scheme >= 0 {
+			fakeGdbus(v.scheme)
+			bin = gdbus
+		}
+		dark, ok := detectDarkMode(bin, v.config, v.gtkTheme)
+		if dark != v.dark || ok != v.ok {
+			t.Errorf("%v: got (%v, %v), expected (%v, %v)", i, dark, ok, v.dark, v.ok)
+		}
+	}
+
+	// An unresponsive portal falls back to the GTK settings.
+	if err := os.WriteFile(gdbus, []byte("#!/bin/sh\nexec sleep 60\n"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	t0 := time.Now()
+	if dark, ok := detectDarkMode(gdbus, config, ""); !dark || !ok || time.Since(t0) > 2*portalReadTimeout {
+		t.Errorf("unresponsive portal: got (%v, %v) after %v", dark, ok, time.Since(t0))
+	}
+
+	for _, v := range []struct {
+		s      string
+		scheme int
+		ok     bool
+	}{
+		{"(<<uint32 1>>,)", 1, true},
+		{"(<uint32 2>,)", 2, true},
+		{"/org/freedesktop/portal/desktop: org.freedesktop.portal.Settings.SettingChanged ('org.freedesktop.appearance', 'color-scheme', <uint32 1>)", 1, true},
+		{"Error: GDBus.Error", 0, false},
+	} {
+		if scheme, ok := parseColorScheme(v.s); scheme != v.scheme || ok != v.ok {
+			t.Errorf("%q: got (%v, %v), expected (%v, %v)", v.s, scheme, ok, v.scheme, v.ok)
+		}
+	}
+}
+
+func TestThemeAutoStops(t *testing.T) {
+	for _, switching := range []bool{true, false} {
+		stop := make(chan struct{})
+		canceled := false
+		themeAuto.stop, themeAuto.cancel, themeAuto.switching = stop, func() { canceled = true }, switching
+		notifyThemeChanged(ThemeKey{Name: "a"}, ThemeKey{Name: "b"})
+		themeAuto.switching = false
+		if g, e := themeAuto.stop == nil, !switching; g != e {
+			t.Errorf("switching=%v: stopped %v, expected %v", switching, g, e)
+		}
+
+		if g, e := canceled, !switching; g != e {
+			t.Errorf("switching=%v: monitor canceled %v, expected %v", switching, g, e)
+		}
+	}
+	StopThemeAuto()
+}
+
+func TestThemeSpec(t *testing.T) {
+	spec, err := LoadThemeSpec(strings.NewReader(`{
+	"name": "ocean",
+	"variant": {"Family": "Ocean", "Dark": true},
+	"palette": {"bg": "#102030", "fg": "white"},
+	"fonts": {"ui": {"family": "DejaVu Sans", "size": 10, "weight": "bold"}},
+	"elements": {
+		"Ocean.TButton.border": {
+			"image": {"svg": "<svg/>"},
+			"states": [{"state": "pressed", "svg": "<svg/>"}],
+			"border": "4",
+			"sticky": "nsew"
+		}
+	},
+	"styles": {
+		".": {"configure": {"background": "$bg", "font": "$ui"}},
+		"TButton": {
+			"configure": {"-padding": "8 4"},
+			"map": {"foreground": [{"state": "disabled", "value": "gray"}, {"state": "!disabled", "value": "$fg"}]}
+		}
+	}
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if spec.Variant == nil || !spec.Variant.Dark {
+		t.Fatalf("variant: %+v", spec.Variant)
+	}
+
+	g, err := spec.script(map[string][]string{"Ocean.TButton.border": {"img1", "img2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := `ttk::style theme create ocean -parent clam -settings {
+	ttk::style element create Ocean.TButton.border image [list img1 pressed img2] -border 4 -sticky nsew
+	ttk::style configure . -background #102030 -font [list DejaVu\x20Sans 10 bold]
+	ttk::style configure TButton -padding 8\x204
+	ttk::style map TButton -foreground [list disabled gray !disabled white]
+}`
+	if g != e {
+		t.Errorf("got\n%s\nexpected\n%s", g, e)
+	}
+
+	if _, err := LoadThemeSpec(strings.NewReader(`{"name": "x", "styles": {".": {"configure": {"background": "$nope"}}}}`)); err == nil {
+		t.Error("expected undefined name error")
+	}
+
+	if _, err := LoadThemeSpec(strings.NewReader(`{"styles": {}}`)); err == nil {
+		t.Error("expected missing name error")
+	}
+
+	spec, err = LoadThemeSpecTOML(strings.NewReader(`# Same as above.
+name = "ocean"
+variant = { family = "Ocean", dark = true }
+
+[palette]
+bg = "#102030"
+fg = 'white'
+
+[fonts.ui]
+family = "DejaVu Sans"
+size = 10
+weight = "bold"
+
+[elements."Ocean.TButton.border"]
+image.svg = """
+<svg/>"""
+states = [
+	{ state = "pressed", svg = "<svg/>" }, # Trailing comma.
+]
+border = "4"
+sticky = "nsew"
+
+[styles.".".configure]
+background = "$bg"
+font = "$ui"
+
+[styles.TButton]
+configure = { -padding = "8 4" }
+
+[[styles.TButton.map.foreground]]
+state = "disabled"
+value = "gray"
+
+[[styles.TButton.map.foreground]]
+state = "!disabled"
+value = "$fg"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if g, err = spec.script(map[string][]string{"Ocean.TButton.border": {"img1", "img2"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if g != e {
+		t.Errorf("TOML: got\n%s\nexpected\n%s", g, e)
+	}
+
+	for _, v := range []string{
+		"name = \"x\"\nname = \"y\"",
+		"name = \"x\"\nnope = 1",
+		"name = \"x\" garbage",
+		"name = \"x",
+		"[styles",
+		"name = \"x\"\na = []\n[a.b]",
+		"name = \"x\"\na = []\n[[a.b]]",
+		"name = \"x\"\na = [1]\n[a.b]",
+	} {
+		if _, err := LoadThemeSpecTOML(strings.NewReader(v)); err == nil {
+			t.Errorf("%q: expected error", v)
+		}
+	}
+}
+
+func TestParseTOML(t *testing.T) {
+	m, err := parseTOML(`
+a = "x\ty\u00e9\"" # Comment.
+b = 'C:\path'
+c = -1_000
+d = 1.5e3
+e = [1, [true, false], "s"]
+f.g."h.i" = 0x10
+j = """
+one \
+	two"""
+k = '''
+raw \n'''''
+
+[[t]]
+n = 1
+
+[[t]]
+n = 2
+
+[t.u]
+v = {}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if g, e := string(b), `{"a":"x\tyé\"","b":"C:\\path","c":-1000,"d":1500,"e":[1,[true,false],"s"],"f":{"g":{"h.i":16}},"j":"one two","k":"raw \\n''","t":[{"n":1},{"n":2,"u":{"v":{}}}]}`; g != e {
+		t.Errorf("got\n%s\nexpected\n%s", g, e)
+	}
+}
+
+func TestUndoManagerHistory(t *testing.T) {
+	m := &UndoManager{}
+	changes := 0
+	m.OnChange(func() { changes++ })
+	for _, v := range []Edit{
+		{EditInsert, "1.0", "a"},
+		{EditInsert, "1.1", "b"},
+		{EditInsert, "1.2", "c"},
+		{EditInsert, "1.3", "\n"},
+		{EditDelete, "2.2", "y"},
+		{EditDelete, "2.1", "x"},
+	} {
+		m.record(v)
+	}
+	m.BeginGroup()
+	m.record(Edit{EditInsert, "3.0", "p"})
+	m.BeginGroup()
+	m.record(Edit{EditInsert, "3.1", "q"})
+	m.EndGroup()
+	if g, e := len(m.UndoStack()), 3; g != e {
+		t.Fatalf("open group recorded early: got %v groups, expected %v", g, e)
+	}
+
+	m.EndGroup()
+	g, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := `{"undo":[[{"kind":0,"index":"1.0","text":"abc"}],[{"kind":0,"index":"1.3","text":"\n"}],[{"kind":1,"index":"2.1","text":"xy"}],[{"kind":0,"index":"3.0","text":"p"},{"kind":0,"index":"3.1","text":"q"}]],"redo":null}`
+	if string(g) != e {
+		t.Errorf("got\n%s\nexpected\n%s", g, e)
+	}
+
+	if !m.CanUndo() || m.CanRedo() || changes == 0 {
+		t.Errorf("CanUndo %v, CanRedo %v, changes %v", m.CanUndo(), m.CanRedo(), changes)
+	}
+
+	m.SetMaxDepth(2)
+	if g, e := m.UndoStack()[0][0].Text, "xy"; g != e {
+		t.Errorf("SetMaxDepth: got %q, expected %q", g, e)
+	}
+
+	var m2 UndoManager
+	if err := json.Unmarshal([]byte(e), &m2); err != nil {
+		t.Fatal(err)
+	}
+
+	if g, e := len(m2.UndoStack()), 4; g != e {
+		t.Errorf("UnmarshalJSON: got %v groups, expected %v", g, e)
+	}
+}
+
+func TestUndoManager(t *testing.T) {
+	needTk(t)
+	w := Text()
+	defer Destroy(w)
+	m := NewUndoManager(w)
+	defer m.Close()
+	w.Insert("end", "hello world")
+	m.Separator()
+	w.Delete("1.0", "1.6")
+	w.Replace("1.0", "1.5", "there")
+	if g, e := w.Text(), "there"; g != e {
+		t.Fatalf("got %q, expected %q", g, e)
+	}
+
+	for _, v := range []string{"world", "hello world", ""} {
+		m.Undo()
+		if g := w.Text(); g != v {
+			t.Errorf("undo: got %q, expected %q", g, v)
+		}
+	}
+	for _, v := range []string{"hello world", "world", "there"} {
+		m.Redo()
+		if g := w.Text(); g != v {
+			t.Errorf("redo: got %q, expected %q", g, v)
+		}
+	}
+	if g, e := len(m.UndoStack()[2]), 2; g != e {
+		t.Errorf("replace: got %v edits, expected %v", g, e)
+	}
+}
+
+func TestFindText(t *testing.T) {
+	text := "héllo wörld\nsay héllo"
+	var g []string
+	for _, v := range findText(text, regexp.MustCompile(`h(é)llo`), true) {
+		x, _ := v.expand("<$1>")
+		g = append(g, fmt.Sprintf("%s-%s:%s", v.Start, v.End, x))
+	}
+	if g, e := strings.Join(g, " "), "1.0-1.5:<é> 2.4-2.9:<é>"; g != e {
+		t.Errorf("got %q, expected %q", g, e)
+	}
+
+	if g, _ := findText(text, regexp.MustCompile(`ö`), false)[0].expand("$1"); g != "$1" {
+		t.Errorf("literal: got %q, expected %q", g, "$1")
+	}
+}
+
+// needTcl skips the test if the Tcl interpreter is not available. The Tcl
+// commands work even if Tk failed to initialize, for example without a
+// display.
+func needTcl(t *testing.T) {
+	t.Helper()
+	// The first call reports Tk initialization errors, if any.
+	eval("info patchlevel")
+	if _, err := eval("info patchlevel"); err != nil {
+		t.Skip(err)
+	}
+}
+
+func TestTclExpand(t *testing.T) {
+	needTcl(t)
+	for i, v := range []struct {
+		pattern, text, template string
+		nocase                  bool
+		e                       string
+	}{
+		{`h(é)llo`, "héllo", `<\1>`, false, "<é>"},
+		{`H(É)LLO`, "héllo", `<\1>`, true, "<é>"},
+		{`^a(b)`, "ab", `\1&`, false, "bab"},
+		{`a b`, "a b", `[x] $y`, false, "[x] $y"},
+		{`a(?=b)`, "a", `x`, false, ""},
+		{`x|xy(?=z)`, "xy", `<&>`, false, ""},
+	} {
+		g, err := tclExpand(v.pattern, v.text, v.template, v.nocase)
+		if (err != nil) != (v.e == "") || g != v.e {
+			t.Errorf("%v: got %q, %v, expected %q", i, g, err, v.e)
+		}
+	}
+}
+
+func TestFindWholeWordTcl(t *testing.T) {
+	if _, err := (&TextWidget{}).findAll("x", FindOptions{WholeWord: true, Tcl: true}); err == nil {
+		t.Error("unexpected success")
+	}
+}
+
+func TestValidators(t *testing.T) {
+	key := func(s string) ValidationEvent { return ValidationEvent{Proposed: s, Reason: "key"} }
+	forced := func(s string) ValidationEvent { return ValidationEvent{Proposed: s, Reason: "forced"} }
+	for i, v := range []struct {
+		f  Validator
+		e  ValidationEvent
+		ok bool
+	}{
+		{IntegerRange(10, 20), key(""), true},
+		{IntegerRange(10, 20), key("1"), true},
+		{IntegerRange(10, 20), key("21"), false},
+		{IntegerRange(10, 20), key("-"), false},
+		{IntegerRange(10, 20), forced("1"), false},
+		{IntegerRange(-5, 5), key("-"), true},
+		{IntegerRange(-5, 5), forced("-5"), true},
+		{IntegerRange(10, 20), key("5"), false},
+		{IntegerRange(10, 20), key("2"), true},
+		{IntegerRange(10, 20), key("3"), false},
+		{IntegerRange(10, 20), key("+1"), true},
+		{IntegerRange(10, 20), key("-1"), false},
+		{IntegerRange(10, 20), key("1x"), false},
+		{IntegerRange(-20, -10), key("-"), true},
+		{IntegerRange(-20, -10), key("-1"), true},
+		{IntegerRange(-20, -10), key("-3"), false},
+		{IntegerRange(-20, -10), key("-21"), false},
+		{IntegerRange(-20, -10), key("1"), false},
+		{IntegerRange(-20, -10), key("+"), false},
+		{IntegerRange(-20, -10), forced("-1"), false},
+		{IntegerRange(-20, -10), forced("-15"), true},
+		{IntegerRange(-5, 5), key("-6"), false},
+		{IntegerRange(-5, 5), key("-0"), true},
+		{IntegerRange(-5, 5), key("+"), true},
+		{IntegerRange(0, 0), key("-"), true},
+		{IntegerRange(math.MinInt, math.MaxInt), key("-9223372036854775808"), true},
+		{IntegerRange(math.MinInt, math.MaxInt), key("9223372036854775808"), false},
+		{IntegerRange(0, math.MaxInt), key("92233720368547758"), true},
+		{Float(), key("-1.5e"), true},
+		{Float(), forced("-1.5e"), false},
+		{Float(), forced("-1.5e3"), true},
+		{MatchRegexp(regexp.MustCompile(`^[a-z]*$`)), key("abc"), true},
+		{MatchRegexp(regexp.MustCompile(`^[a-z]*$`)), key("aBc"), false},
+		{MaxLength(3), key("héé"), true},
+		{MaxLength(3), key("héél"), false},
+		{Date("2006-01-02"), key("2025-0"), true},
+		{Date("2006-01-02"), key("2025/"), false},
+		{Date("2006-01-02"), forced("2025-02-30"), false},
+		{Date("2006-01-02"), forced("2025-02-28"), true},
+		{IPAddress(), key("192.168."), true},
+		{IPAddress(), key("192.256"), false},
+		{IPAddress(), forced("192.168.1"), false},
+		{IPAddress(), forced("192.168.1.1"), true},
+		{Mask(PhoneMask), key("(555) 12"), true},
+		{Mask(PhoneMask), key("(555)x"), false},
+		{Mask(PhoneMask), forced("(555) 123-4567"), true},
+		{Mask(PhoneMask), forced("(555) 123"), false},
+		{AllOf(MaxLength(2), IntegerRange(0, 999)), key("123"), false},
+	} {
+		if g, e := v.f(v.e), v.ok; g != e {
+			t.Errorf("%v: %+v: got %v, expected %v", i, v.e, g, e)
+		}
+	}
+}
@@ -5,6 +5,7 @@
 package b5 // import "modernc.org/tk9.0/b5"
 
 import (
+	"strings"
 	"testing"
 
 	. "modernc.org/tk9.0"
@@ -29,3 +30,112 @@ func Test2(t *testing.T) {
 	const k = 10
 	getCorners(k*round(width), k*round(clip), k*round(r), k*round(stroke), "#0b5ed7", "#97c1fe", "#fff")
 }
+
+func TestMix(t *testing.T) {
+	for i, v := range []struct {
+		a, b string
+		t    float64
+		e    string
+	}{
+		{"#000000", "#ffffff", 0, "#000000"},
+		{"#000000", "#ffffff", 1, "#ffffff"},
+		{"#000", "#fff", .5, "#808080"},
+		{"#0d6efd", "#000000", .15, "#0b5ed7"},
+		{"white", "#000000", .5, "white"},
+	} {
+		if g, e := mix(v.a, v.b, v.t), v.e; g != e {
+			t.Errorf("%v: got %s, expected %s", i, g, e)
+		}
+	}
+}
+
+func TestStyleSetColors(t *testing.T) {
+	th := &StyleSet{colors: Colors{ButtonFace: "#111111", FieldFace: "#222222"}}
+	m := th.merge(Colors{ButtonFace: "#333333"})
+	if g, e := m[ButtonFace], "#333333"; g != e {
+		t.Errorf("ButtonFace: got %s, expected %s", g, e)
+	}
+	if g, e := m[FieldFace], "#222222"; g != e {
+		t.Errorf("FieldFace: got %s, expected %s", g, e)
+	}
+	if g, e := th.colors[ButtonFace], "#111111"; g != e {
+		t.Errorf("palette modified: got %s, expected %s", g, e)
+	}
+	for _, v := range []struct {
+		color string
+		dark  bool
+	}{
+		{"#ffffff", false},
+		{"#212529", true},
+		{"#0d6efd", true},
+		{"#ffc107", false},
+		{"white", false},
+	} {
+		if g, e := isDark(v.color), v.dark; g != e {
+			t.Errorf("isDark(%s): got %v, expected %v", v.color, g, e)
+		}
+	}
+}
+
+// needTk skips the test if Tk cannot be initialized, for example without a
+// display.
+func needTk(t *testing.T) {
+	t.Helper()
+
+	defer func() {
+		if err := recover(); err != nil {
+			t.Skip(err)
+		}
+	}()
+
+	TkScaling()
+}
+
+func TestStyleAgain(t *testing.T) {
+	needTk(t)
+	for _, v := range []func(){
+		func() { EntryStyle("Again.TEntry", PrimaryColors, "#ffffff") },
+		func() { SwitchStyle("Again.TCheckbutton", PrimaryColors, "#ffffff") },
+		func() { CardStyle("AgainCard", PrimaryColors, "#ffffff") },
+	} {
+		v()
+		v() // Must not fail on duplicate elements.
+	}
+	if g := StyleLayout("Again.TEntry"); !strings.Contains(g, "Again.TEntry.b5s") {
+		t.Errorf("layout does not use the new elements: %s", g)
+	}
+}
+
+func TestStyleSetCaches(t *testing.T) {
+	needTk(t)
+	prev := StyleThemeUse()
+	s, err := NewStyleSet("b5 caches", PrimaryColors, "#ffffff")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer Themes[s.Key()].Finalize(nil)
+
+	s.EntryStyle("Caches.TEntry", nil)
+	c := s.caches[prev]
+	if c == nil || c.spacer == nil {
+		t.Fatalf("no images cached for %s", prev)
+	}
+
+	if err := Themes[s.Key()].Activate(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if g, e := StyleThemeUse(), "b5 caches"; g != e {
+		t.Fatalf("got theme %s, expected %s", g, e)
+	}
+
+	// The styles created in 'prev' still use their images.
+	if s.caches[prev] != c || c.spacer == nil {
+		t.Errorf("images of %s deleted", prev)
+	}
+
+	if s.caches["b5 caches"] == nil {
+		t.Error("no images cached for b5 caches")
+	}
+}
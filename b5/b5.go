@@ -4,6 +4,20 @@
 
 // The b5 package is a work in progress with no stable API yet. It will eventually become
 // a full theme package.
+//
+// The component styles, like [SizedButtonStyle], [EntryStyle] or
+// [ProgressbarStyle], draw Bootstrap 5 like widgets using a [Colors] palette,
+// see for example [PrimaryColors]. Their layouts are built only from image
+// elements with rounded corners, so they work on top of any base theme.
+//
+// A [StyleSet] owns a palette and the images of its styles. It recreates the
+// styles when the palette or the display scaling changes and it can be
+// activated like any other theme registered in [tk.Themes]:
+//
+//	t, err := b5.NewStyleSet("b5 light", b5.PrimaryColors, tk.White)
+//	...
+//	t.EntryStyle("b5.TEntry", nil)
+//	tk.ActivateTheme("b5 light")
 package b5 // import "modernc.org/tk9.0/b5"
 
 import (
@@ -20,8 +34,8 @@ const (
 )
 
 var (
-	corners = map[cornerKey][4]*Img{}
-	tiles   = map[tileKey]*Img{}
+	// images is the image cache of the package level style functions.
+	images = newCache()
 )
 
 type Color int
@@ -55,10 +69,50 @@ func round(n float64) int {
 	return int(math.Round(n))
 }
 
+// cache holds the images of a set of styles.
+type cache struct {
+	corners map[cornerKey][4]*Img
+	tiles   map[tileKey]*Img
+	svgs    map[string]*Img
+	spacer  *Img
+}
+
+func newCache() *cache {
+	return &cache{
+		corners: map[cornerKey][4]*Img{},
+		tiles:   map[tileKey]*Img{},
+		svgs:    map[string]*Img{},
+	}
+}
+
+// delete deletes all images in the cache.
+func (c *cache) delete() {
+	for _, v := range c.corners {
+		for _, w := range v {
+			w.Delete()
+		}
+	}
+	for _, v := range c.tiles {
+		v.Delete()
+	}
+	for _, v := range c.svgs {
+		v.Delete()
+	}
+	if c.spacer != nil {
+		c.spacer.Delete()
+	}
+	*c = *newCache()
+}
+
 // All sizes in px
 func getCorners(width, clip, r, strokeWidth int, fill, stroke, background string) (re [4]*Img) {
+	return images.getCorners(width, clip, r, strokeWidth, fill, stroke, background)
+}
+
+// All sizes in px
+func (c *cache) getCorners(width, clip, r, strokeWidth int, fill, stroke, background string) (re [4]*Img) {
 	k := cornerKey{width, clip, r, strokeWidth, fill, stroke, background}
-	if ex, ok := corners[k]; ok {
+	if ex, ok := c.corners[k]; ok {
 		return ex
 	}
 
@@ -76,20 +130,26 @@ func getCorners(width, clip, r, strokeWidth int, fill, stroke, background string
 	re[2].Copy(img, From(width-clip, width, width, width+clip))
 	re[3] = NewPhoto(Width(clip), Height(clip))
 	re[3].Copy(img, From(width, width, width+clip, width+clip))
-	corners[k] = re
+	img.Delete()
+	c.corners[k] = re
 	return re
 }
 
 // All sizes in px
 func getTile(width, height int, color string) (r *Img) {
+	return images.getTile(width, height, color)
+}
+
+// All sizes in px
+func (c *cache) getTile(width, height int, color string) (r *Img) {
 	k := tileKey{width, height, color}
-	if ex, ok := tiles[k]; ok {
+	if ex, ok := c.tiles[k]; ok {
 		return ex
 	}
 
 	r = NewPhoto(Width(width), Height(height),
 		Data(fmt.Sprintf(`<svg width="%d" height="%d" fill=%q><rect width="%[1]d" height="%d" fill=%q/></svg>`, width, height, color)))
-	tiles[k] = r
+	c.tiles[k] = r
 	return r
 }
 
@@ -0,0 +1,520 @@
+// Copyright 2025 The tk9.0-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package b5 // import "modernc.org/tk9.0/b5"
+
+import (
+	"fmt"
+	"strings"
+
+	tk "modernc.org/tk9.0"
+)
+
+// Sizes in px on a 96 DPI display.
+const (
+	buttonStroke        = 4
+	outlineButtonStroke = 2
+	fieldCorner         = 6
+	fieldStroke         = 2
+	frameCorner         = 6
+	frameStroke         = 1
+	progressbarHeight   = 16
+	switchWidth         = 32
+	switchHeight        = 16
+	switchGap           = 8
+)
+
+// ButtonSize selects the size of a button.
+type ButtonSize int
+
+const (
+	Medium ButtonSize = iota
+	Small
+	Large
+)
+
+func (s ButtonSize) corner(b *builder) int {
+	switch s {
+	case Small:
+		return b.px(6)
+	case Large:
+		return b.px(12)
+	default:
+		return b.px(9)
+	}
+}
+
+func (s ButtonSize) padding(b *builder) string {
+	switch s {
+	case Small:
+		return b.pad(8, 2)
+	case Large:
+		return b.pad(16, 8)
+	default:
+		return b.pad(12, 5)
+	}
+}
+
+// builder creates the elements of styles using the images in 'cache'. The
+// display scaling is queried once, when the builder is created.
+type builder struct {
+	cache  *cache
+	prefix string // Makes element names unique among rebuilds of a style.
+	scale  float64
+}
+
+func newBuilder(c *cache, prefix string) *builder {
+	return &builder{cache: c, prefix: prefix, scale: tk.TkScaling() * 72 / 96}
+}
+
+// styleGen numbers the element names of styles created again by the package
+// level style functions.
+var styleGen int
+
+// styleBuilder returns the builder used by the package level style functions
+// to create the style or styles 'name'. Ttk elements cannot be redefined, so if
+// elements of 'name' already exist in the current ttk theme, the builder
+// derives new, unique element names.
+func styleBuilder(name string) *builder {
+	prefix := ""
+	for _, v := range tk.StyleElementNames() {
+		if strings.HasPrefix(v, name+".") {
+			styleGen++
+			prefix = fmt.Sprintf("b5s%d", styleGen)
+			break
+		}
+	}
+	return newBuilder(images, prefix)
+}
+
+// px converts 'n' px on a 96 DPI display to px on the current display.
+func px(n float64) int {
+	return max(1, round(tk.TkScaling()*72*n/96))
+}
+
+// pad returns a ttk padding specification of 'x' and 'y' px on a 96 DPI
+// display.
+func pad(x, y float64) string {
+	return fmt.Sprintf("%d %d", px(x), px(y))
+}
+
+func (b *builder) px(n float64) int {
+	return max(1, round(b.scale*n))
+}
+
+func (b *builder) pad(x, y float64) string {
+	return fmt.Sprintf("%d %d", b.px(x), b.px(y))
+}
+
+// element returns the name of the element 'part' of 'style'.
+func (b *builder) element(style, part string) string {
+	if b.prefix == "" {
+		return style + "." + part
+	}
+
+	return style + "." + b.prefix + "." + part
+}
+
+// getSpacer returns a transparent image used by the padding elements.
+func (c *cache) getSpacer() *tk.Img {
+	if c.spacer == nil {
+		c.spacer = tk.NewPhoto(tk.Width(1), tk.Height(1))
+	}
+	return c.spacer
+}
+
+func (c *cache) getSVG(svg string) (r *tk.Img) {
+	if ex, ok := c.svgs[svg]; ok {
+		return ex
+	}
+
+	r = tk.NewPhoto(tk.Data(svg))
+	c.svgs[svg] = r
+	return r
+}
+
+// paint are the colors of a rounded box.
+type paint struct {
+	face   string
+	stroke string
+}
+
+// statePaint are the colors of a rounded box in the widget states matching
+// spec, eg. "focus" or "active !disabled".
+type statePaint struct {
+	spec string
+	paint
+}
+
+// stateImages returns a ttk image element specification using 'f' to produce
+// the images of the normal and state paints.
+func stateImages(normal paint, states []statePaint, f func(paint) *tk.Img) (r []any) {
+	r = append(r, f(normal))
+	for _, v := range states {
+		r = append(r, v.spec, f(v.paint))
+	}
+	return r
+}
+
+// box creates the image elements of a rounded box with corners of size
+// 'corner' and a border 'stroke' px wide, drawn over 'background'. It returns
+// the style layout placing 'content' inside the box padded by 'padding'.
+//
+// The box is built only from image elements so it does not depend on the
+// elements of the current base theme.
+func (b *builder) box(style, background string, corner, stroke int, padding string, normal paint, states []statePaint, content ...any) []any {
+	r := round(float64(corner) - float64(stroke)/2)
+	clip := max(1, corner-stroke)
+	ring := b.element(style, "ring")
+	inset := b.element(style, "inset")
+	face := b.element(style, "face")
+	pad := b.element(style, "pad")
+	tk.StyleElementCreate(ring, "image", stateImages(normal, states, func(p paint) *tk.Img { return b.cache.getTile(8, 8, p.stroke) })...)
+	tk.StyleElementCreate(face, "image", stateImages(normal, states, func(p paint) *tk.Img { return b.cache.getTile(8, 8, p.face) })...)
+	tk.StyleElementCreate(inset, "image", b.cache.getSpacer(), tk.Padding(stroke))
+	tk.StyleElementCreate(pad, "image", b.cache.getSpacer(), tk.Padding(padding))
+	var outer, inner []any
+	for i, sticky := range []string{"ne", "nw", "sw", "se"} {
+		o := b.element(style, fmt.Sprintf("o%d", i+1))
+		tk.StyleElementCreate(o, "image", stateImages(normal, states, func(p paint) *tk.Img {
+			return b.cache.getCorners(corner, corner, r, stroke, p.face, p.stroke, background)[i]
+		})...)
+		outer = append(outer, o, tk.Sticky(sticky))
+		in := b.element(style, fmt.Sprintf("i%d", i+1))
+		tk.StyleElementCreate(in, "image", stateImages(normal, states, func(p paint) *tk.Img {
+			return b.cache.getCorners(corner, clip, r, stroke, p.face, p.stroke, background)[i]
+		})...)
+		inner = append(inner, in, tk.Sticky(sticky))
+	}
+	inner = append(inner, pad, tk.Sticky("nswe"), tk.Children(content...))
+	outer = append(outer, inset, tk.Sticky("nswe"), tk.Children(face, tk.Sticky("nswe"), tk.Children(inner...)))
+	return []any{ring, tk.Sticky("nswe"), tk.Children(outer...)}
+}
+
+// flat creates the image elements of a rectangle filled with 'fill', or with
+// the face colors of the state paints. It returns the style layout placing
+// 'content' inside the rectangle padded by 'padding'.
+func (b *builder) flat(style, fill, padding string, states []statePaint, content ...any) []any {
+	bg := b.element(style, "bg")
+	pad := b.element(style, "pad")
+	tk.StyleElementCreate(bg, "image", stateImages(paint{face: fill}, states, func(p paint) *tk.Img { return b.cache.getTile(8, 8, p.face) })...)
+	tk.StyleElementCreate(pad, "image", b.cache.getSpacer(), tk.Padding(padding))
+	return []any{bg, tk.Sticky("nswe"), tk.Children(pad, tk.Sticky("nswe"), tk.Children(content...))}
+}
+
+// SizedButtonStyle defines a TButton style filled with the ButtonFace color.
+// The focus ring is drawn in the ButtonFocus color when the button has the
+// keyboard focus.
+func SizedButtonStyle(style string, colors Colors, background string, size ButtonSize) string {
+	return styleBuilder(style).sizedButtonStyle(style, colors, background, size)
+}
+
+func (b *builder) sizedButtonStyle(style string, colors Colors, background string, size ButtonSize) string {
+	face := colors.get(ButtonFace)
+	focus := colors.get(ButtonFocus)
+	text := colors.get(ButtonText)
+	hover := mix(face, "#000000", .15)
+	tk.StyleLayout(style, b.box(style, background, size.corner(b), b.px(buttonStroke), size.padding(b),
+		paint{face, background},
+		[]statePaint{
+			{"disabled", paint{mix(face, background, .35), background}},
+			{"pressed", paint{hover, focus}},
+			{"focus", paint{face, focus}},
+			{"active", paint{hover, background}},
+		},
+		"Button.label", tk.Sticky("nswe"))...)
+	tk.StyleConfigure(style, tk.Anchor("center"), tk.Background(background), tk.Foreground(text))
+	tk.StyleMap(style, tk.Foreground, "disabled", mix(text, face, .35))
+	return style
+}
+
+// OutlineButtonStyle defines a TButton style with a ButtonFace colored border
+// and text. The button is filled with the ButtonFace color when the mouse is
+// over it.
+func OutlineButtonStyle(style string, colors Colors, background string, size ButtonSize) string {
+	return styleBuilder(style).outlineButtonStyle(style, colors, background, size)
+}
+
+func (b *builder) outlineButtonStyle(style string, colors Colors, background string, size ButtonSize) string {
+	face := colors.get(ButtonFace)
+	focus := colors.get(ButtonFocus)
+	text := colors.get(ButtonText)
+	tk.StyleLayout(style, b.box(style, background, size.corner(b), b.px(outlineButtonStroke), size.padding(b),
+		paint{background, face},
+		[]statePaint{
+			{"disabled", paint{background, mix(face, background, .35)}},
+			{"pressed", paint{face, focus}},
+			{"focus active", paint{face, focus}},
+			{"focus", paint{background, focus}},
+			{"active", paint{face, face}},
+		},
+		"Button.label", tk.Sticky("nswe"))...)
+	tk.StyleConfigure(style, tk.Anchor("center"), tk.Background(background), tk.Foreground(face))
+	tk.StyleMap(style, tk.Foreground, "disabled", mix(face, background, .35), "pressed", text, "active", text)
+	return style
+}
+
+// field returns the layout of an entry-like widget with a focus ring.
+func (b *builder) field(style string, colors Colors, background string, content ...any) []any {
+	face := colors.get(FieldFace)
+	border := colors.get(FieldBorder)
+	return b.box(style, background, b.px(fieldCorner), b.px(fieldStroke), b.pad(10, 4),
+		paint{face, border},
+		[]statePaint{
+			{"disabled", paint{colors.get(Track), border}},
+			{"focus", paint{face, colors.get(ButtonFocus)}},
+		},
+		content...)
+}
+
+// configureField sets the text colors of an entry-like style.
+func configureField(style string, colors Colors) {
+	tk.StyleConfigure(style, tk.Background(colors.get(FieldFace)), tk.Fieldbackground(colors.get(FieldFace)),
+		tk.Foreground(colors.get(FieldText)), tk.Insertcolor(colors.get(FieldText)),
+		tk.Selectbackground(colors.get(ButtonFace)), tk.Selectforeground(colors.get(ButtonText)))
+	tk.StyleMap(style, tk.Foreground, "disabled", colors.get(Muted))
+}
+
+// EntryStyle defines a TEntry style with rounded corners and a focus ring in
+// the ButtonFocus color.
+func EntryStyle(style string, colors Colors, background string) string {
+	return styleBuilder(style).entryStyle(style, colors, background)
+}
+
+func (b *builder) entryStyle(style string, colors Colors, background string) string {
+	tk.StyleLayout(style, b.field(style, colors, background, "Entry.textarea", tk.Sticky("nswe"))...)
+	configureField(style, colors)
+	return style
+}
+
+// ComboboxStyle defines a TCombobox style with rounded corners and a focus
+// ring in the ButtonFocus color.
+func ComboboxStyle(style string, colors Colors, background string) string {
+	return styleBuilder(style).comboboxStyle(style, colors, background)
+}
+
+func (b *builder) comboboxStyle(style string, colors Colors, background string) string {
+	tk.StyleLayout(style, b.field(style, colors, background,
+		"Combobox.downarrow", tk.Side("right"), tk.Sticky("ns"),
+		"Combobox.textarea", tk.Sticky("nswe"))...)
+	configureField(style, colors)
+	tk.StyleConfigure(style, tk.Arrowcolor(colors.get(FieldText)))
+	return style
+}
+
+// BadgeStyle defines a TLabel style drawing the label as a small pill filled
+// with the ButtonFace color.
+func BadgeStyle(style string, colors Colors, background string) string {
+	return styleBuilder(style).badgeStyle(style, colors, background)
+}
+
+func (b *builder) badgeStyle(style string, colors Colors, background string) string {
+	face := colors.get(ButtonFace)
+	tk.StyleLayout(style, b.box(style, background, b.px(8), b.px(1), b.pad(7, 2), paint{face, face}, nil,
+		"Label.label", tk.Sticky("nswe"))...)
+	tk.StyleConfigure(style, tk.Anchor("center"), tk.Background(face), tk.Foreground(colors.get(ButtonText)), tk.Font("TkSmallCaptionFont"))
+	return style
+}
+
+// AlertStyle defines the styles of an alert, a frame filled with the Subtle
+// color holding a text in the Emphasis color. The styles are named 'name'
+// followed by ".TFrame" and ".TLabel". AlertStyle returns 'name', which can be
+// passed to [Alert].
+func AlertStyle(name string, colors Colors, background string) string {
+	return styleBuilder(name).alertStyle(name, colors, background)
+}
+
+func (b *builder) alertStyle(name string, colors Colors, background string) string {
+	frame := name + ".TFrame"
+	label := name + ".TLabel"
+	subtle := colors.get(Subtle)
+	tk.StyleLayout(frame, b.box(frame, background, b.px(frameCorner), b.px(frameStroke), "0", paint{subtle, colors.get(SubtleBorder)}, nil)...)
+	tk.StyleConfigure(frame, tk.Background(subtle))
+	tk.StyleLayout(label, b.flat(label, subtle, "0", nil, "Label.label", tk.Sticky("nswe"))...)
+	tk.StyleConfigure(label, tk.Background(subtle), tk.Foreground(colors.get(Emphasis)))
+	return name
+}
+
+// Alert creates an alert showing 'text' in 'parent' using the styles created
+// by [AlertStyle]('name', ...). A nil 'parent' means [tk.App].
+func Alert(parent *tk.Window, name, text string, options ...tk.Opt) *tk.TFrameWidget {
+	if parent == nil {
+		parent = tk.App
+	}
+	r := parent.TFrame(tk.Style(name+".TFrame"), tk.Padding(pad(16, 12)))
+	tk.Pack(r.TLabel(append([]tk.Opt{tk.Style(name + ".TLabel"), tk.Txt(text), tk.Anchor("w"), tk.Justify("left")}, options...)...), tk.Fill("both"), tk.Expand(true))
+	return r
+}
+
+// CardStyle defines the styles of a card, a bordered frame with a header. The
+// styles are named 'name' followed by ".TFrame", ".Header.TLabel",
+// ".Body.TFrame" and ".Separator.TFrame". CardStyle returns 'name', which can
+// be passed to [Card].
+func CardStyle(name string, colors Colors, background string) string {
+	return styleBuilder(name).cardStyle(name, colors, background)
+}
+
+func (b *builder) cardStyle(name string, colors Colors, background string) string {
+	frame := name + ".TFrame"
+	header := name + ".Header.TLabel"
+	body := name + ".Body.TFrame"
+	separator := name + ".Separator.TFrame"
+	face := colors.get(FieldFace)
+	border := colors.get(FieldBorder)
+	headerFace := mix(face, colors.get(FieldText), .03)
+	tk.StyleLayout(frame, b.box(frame, background, b.px(frameCorner), b.px(frameStroke), "0", paint{face, border}, nil)...)
+	tk.StyleConfigure(frame, tk.Background(face))
+	tk.StyleLayout(header, b.flat(header, headerFace, b.pad(16, 8), nil, "Label.label", tk.Sticky("nswe"))...)
+	tk.StyleConfigure(header, tk.Background(headerFace), tk.Foreground(colors.get(FieldText)))
+	tk.StyleLayout(body, b.flat(body, face, "0", nil)...)
+	tk.StyleConfigure(body, tk.Background(face))
+	tk.StyleLayout(separator, b.flat(separator, border, "0", nil)...)
+	return name
+}
+
+// Card creates a card titled 'title' in 'parent' using the styles created by
+// [CardStyle]('name', ...). It returns the card and its body frame, where the
+// card content goes. A nil 'parent' means [tk.App].
+func Card(parent *tk.Window, name, title string) (card, body *tk.TFrameWidget) {
+	if parent == nil {
+		parent = tk.App
+	}
+	card = parent.TFrame(tk.Style(name+".TFrame"), tk.Padding(px(frameStroke)))
+	tk.Pack(card.TLabel(tk.Style(name+".Header.TLabel"), tk.Txt(title), tk.Anchor("w")), tk.Fill("x"))
+	tk.Pack(card.TFrame(tk.Style(name+".Separator.TFrame"), tk.Height(px(frameStroke))), tk.Fill("x"))
+	body = card.TFrame(tk.Style(name+".Body.TFrame"), tk.Padding(pad(16, 16)))
+	tk.Pack(body, tk.Fill("both"), tk.Expand(true))
+	return card, body
+}
+
+// ListGroupStyle defines the styles of a list group, a bordered frame holding
+// a column of selectable items. The selected item is filled with the
+// ButtonFace color. The styles are named 'name' followed by ".TFrame",
+// ".TRadiobutton" and ".Separator.TFrame". ListGroupStyle returns 'name',
+// which can be passed to [ListGroup].
+func ListGroupStyle(name string, colors Colors, background string) string {
+	return styleBuilder(name).listGroupStyle(name, colors, background)
+}
+
+func (b *builder) listGroupStyle(name string, colors Colors, background string) string {
+	frame := name + ".TFrame"
+	item := name + ".TRadiobutton"
+	separator := name + ".Separator.TFrame"
+	face := colors.get(FieldFace)
+	border := colors.get(FieldBorder)
+	selected := colors.get(ButtonFace)
+	tk.StyleLayout(frame, b.box(frame, background, b.px(frameCorner), b.px(frameStroke), "0", paint{face, border}, nil)...)
+	tk.StyleConfigure(frame, tk.Background(face))
+	tk.StyleLayout(item, b.flat(item, face, b.pad(16, 8),
+		[]statePaint{
+			{"selected", paint{face: selected}},
+			{"active !disabled", paint{face: mix(face, colors.get(FieldText), .05)}},
+		},
+		"Radiobutton.label", tk.Sticky("nswe"))...)
+	tk.StyleConfigure(item, tk.Anchor("w"), tk.Background(face), tk.Foreground(colors.get(FieldText)))
+	tk.StyleMap(item, tk.Foreground, "disabled", colors.get(Muted), "selected", colors.get(ButtonText))
+	tk.StyleLayout(separator, b.flat(separator, border, "0", nil)...)
+	return name
+}
+
+// ListGroup creates a list group of 'items' in 'parent' using the styles
+// created by [ListGroupStyle]('name', ...). Selecting an item sets 'variable'
+// to the item text. A nil 'parent' means [tk.App].
+func ListGroup(parent *tk.Window, name string, variable *tk.VariableOpt, items ...string) (*tk.TFrameWidget, []*tk.TRadiobuttonWidget) {
+	if parent == nil {
+		parent = tk.App
+	}
+	r := parent.TFrame(tk.Style(name+".TFrame"), tk.Padding(px(frameStroke)))
+	var a []*tk.TRadiobuttonWidget
+	for i, v := range items {
+		if i != 0 {
+			tk.Pack(r.TFrame(tk.Style(name+".Separator.TFrame"), tk.Height(px(frameStroke))), tk.Fill("x"))
+		}
+		options := []tk.Opt{tk.Style(name + ".TRadiobutton"), tk.Txt(v), tk.Value(v)}
+		if variable != nil {
+			options = append(options, variable)
+		}
+		item := r.TRadiobutton(options...)
+		tk.Pack(item, tk.Fill("x"))
+		a = append(a, item)
+	}
+	return r, a
+}
+
+// ProgressbarStyle defines a horizontal TProgressbar style with a rounded
+// trough filled with the Track color. The bar is filled with the ButtonFace
+// color and, if 'striped' is true, with diagonal stripes. The style name must
+// end in "Horizontal.TProgressbar".
+func ProgressbarStyle(style string, colors Colors, background string, striped bool) string {
+	return styleBuilder(style).progressbarStyle(style, colors, background, striped)
+}
+
+func (b *builder) progressbarStyle(style string, colors Colors, background string, striped bool) string {
+	face := colors.get(ButtonFace)
+	track := colors.get(Track)
+	h := b.px(progressbarHeight)
+	bar := b.cache.getTile(8, h, face)
+	if striped {
+		s := float64(h)
+		bar = b.cache.getSVG(fmt.Sprintf(`<svg width="%[1]d" height="%[1]d">
+	<rect width="%[1]d" height="%[1]d" fill=%[2]q />
+	<polygon points="0,%[4]g %[4]g,0 %[5]g,0 0,%[5]g" fill=%[3]q />
+	<polygon points="%[4]g,%[5]g %[5]g,%[4]g %[5]g,%[5]g" fill=%[3]q />
+</svg>`, h, face, mix(face, "#ffffff", .15), s/2, s))
+	}
+	pbar := b.element(style, "pbar")
+	tk.StyleElementCreate(pbar, "image", bar)
+	tk.StyleLayout(style, b.box(style, background, b.px(frameCorner), b.px(frameStroke), "0", paint{track, track}, nil,
+		pbar, tk.Side("left"), tk.Sticky("ns"))...)
+	tk.StyleConfigure(style, tk.Background(track))
+	return style
+}
+
+// SwitchStyle defines a TCheckbutton style drawing the indicator as a toggle
+// switch. The switch is filled with the ButtonFace color when selected and
+// shows a focus ring in the ButtonFocus color when it has the keyboard focus.
+func SwitchStyle(style string, colors Colors, background string) string {
+	return styleBuilder(style).switchStyle(style, colors, background)
+}
+
+func (b *builder) switchStyle(style string, colors Colors, background string) string {
+	face := colors.get(ButtonFace)
+	focus := colors.get(ButtonFocus)
+	fieldFace := colors.get(FieldFace)
+	border := colors.get(FieldBorder)
+	muted := colors.get(Muted)
+	indicator := b.element(style, "indicator")
+	tk.StyleElementCreate(indicator, "image",
+		b.switchImage(fieldFace, border, muted, false),
+		"disabled selected", b.switchImage(mix(face, background, .5), mix(face, background, .5), fieldFace, true),
+		"disabled", b.switchImage(colors.get(Track), border, muted, false),
+		"focus selected", b.switchImage(face, focus, fieldFace, true),
+		"selected", b.switchImage(face, face, fieldFace, true),
+		"focus", b.switchImage(fieldFace, focus, mix(muted, focus, .5), false),
+	)
+	tk.StyleLayout(style, b.flat(style, background, b.pad(0, 2), nil,
+		indicator, tk.Side("left"),
+		"Checkbutton.label", tk.Side("left"), tk.Sticky("nswe"))...)
+	tk.StyleConfigure(style, tk.Background(background), tk.Foreground(colors.get(FieldText)))
+	tk.StyleMap(style, tk.Foreground, "disabled", muted)
+	return style
+}
+
+// switchImage returns the image of a toggle switch followed by a gap
+// separating it from the label.
+func (b *builder) switchImage(track, stroke, knob string, on bool) *tk.Img {
+	w := b.px(switchWidth)
+	h := b.px(switchHeight)
+	sw := b.px(fieldStroke)
+	cx := h / 2
+	if on {
+		cx = w - h/2
+	}
+	return b.cache.getSVG(strings.TrimSpace(fmt.Sprintf(`<svg width="%d" height="%d">
+	<rect x="%g" y="%[3]g" width="%d" height="%d" rx="%g" fill=%q stroke=%q stroke-width="%d" />
+	<circle cx="%d" cy="%d" r="%d" fill=%q />
+</svg>`,
+		w+b.px(switchGap), h, float64(sw)/2, w-sw, h-sw, float64(h-sw)/2, track, stroke, sw,
+		cx, h/2, max(1, h/2-2*sw), knob)))
+}
@@ -0,0 +1,117 @@
+// Copyright 2025 The tk9.0-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package b5 // import "modernc.org/tk9.0/b5"
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Colors used by the component styles in addition to ButtonFace, ButtonFocus
+// and ButtonText. Missing entries fall back to the Bootstrap defaults.
+const (
+	// FieldBorder is the color of entry, card and list group borders.
+	FieldBorder Color = iota + ButtonText + 1
+	// FieldFace is the fill of entries, cards and list groups.
+	FieldFace
+	// FieldText is the color of text drawn on FieldFace.
+	FieldText
+	// Muted is the color of secondary text and of the switch knob when off.
+	Muted
+	// Subtle is the fill of alerts and card headers.
+	Subtle
+	// SubtleBorder is the border color of alerts.
+	SubtleBorder
+	// Emphasis is the color of text drawn on Subtle.
+	Emphasis
+	// Track is the fill of progress bar troughs.
+	Track
+)
+
+var defaultColors = Colors{
+	ButtonFace:   "#0d6efd",
+	ButtonFocus:  "#98c1fe",
+	ButtonText:   "#ffffff",
+	FieldBorder:  "#dee2e6",
+	FieldFace:    "#ffffff",
+	FieldText:    "#212529",
+	Muted:        "#adb5bd",
+	Subtle:       "#f8f9fa",
+	SubtleBorder: "#dee2e6",
+	Emphasis:     "#212529",
+	Track:        "#e9ecef",
+}
+
+// The Bootstrap 5 contextual color palettes.
+var (
+	PrimaryColors   = variant("#0d6efd", "#98c1fe", "#ffffff", "#cfe2ff", "#9ec5fe", "#052c65")
+	SecondaryColors = variant("#6c757d", "#c0c4c8", "#ffffff", "#e2e3e5", "#c4c8cb", "#2b2f32")
+	SuccessColors   = variant("#198754", "#9dccb6", "#ffffff", "#d1e7dd", "#a3cfbb", "#0a3622")
+	DangerColors    = variant("#dc3545", "#f0a9b0", "#ffffff", "#f8d7da", "#f1aeb5", "#58151c")
+	WarningColors   = variant("#ffc107", "#ecd182", "#000000", "#fff3cd", "#ffe69c", "#664d03")
+	InfoColors      = variant("#0dcaf0", "#85d5e5", "#000000", "#cff4fc", "#9eeaf9", "#055160")
+	LightColors     = variant("#f8f9fa", "#e9e9ea", "#000000", "#fcfcfd", "#e9ecef", "#495057")
+	DarkColors      = variant("#212529", "#a0a2a4", "#ffffff", "#ced4da", "#adb5bd", "#495057")
+)
+
+func variant(face, focus, text, subtle, subtleBorder, emphasis string) Colors {
+	return Colors{
+		ButtonFace:   face,
+		ButtonFocus:  focus,
+		ButtonText:   text,
+		Subtle:       subtle,
+		SubtleBorder: subtleBorder,
+		Emphasis:     emphasis,
+	}
+}
+
+// get returns the color 'c' or its default.
+func (m Colors) get(c Color) string {
+	if s := m[c]; s != "" {
+		return s
+	}
+
+	return defaultColors[c]
+}
+
+// mix returns the color 'a' blended with 'b' in ratio 't'. Colors other than
+// #rgb and #rrggbb are returned unchanged.
+func mix(a, b string, t float64) string {
+	ra, ga, ba, ok := rgb(a)
+	if !ok {
+		return a
+	}
+
+	rb, gb, bb, ok := rgb(b)
+	if !ok {
+		return a
+	}
+
+	f := func(x, y int) int { return round(float64(x) + t*float64(y-x)) }
+	return fmt.Sprintf("#%02x%02x%02x", f(ra, rb), f(ga, gb), f(ba, bb))
+}
+
+func rgb(s string) (r, g, b int, ok bool) {
+	if len(s) == 0 || s[0] != '#' {
+		return 0, 0, 0, false
+	}
+
+	s = s[1:]
+	switch len(s) {
+	case 3:
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	case 6:
+		// ok
+	default:
+		return 0, 0, 0, false
+	}
+
+	n, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+
+	return int(n >> 16), int(n >> 8 & 0xff), int(n & 0xff), true
+}
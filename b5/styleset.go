@@ -0,0 +1,237 @@
+// Copyright 2025 The tk9.0-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package b5 // import "modernc.org/tk9.0/b5"
+
+import (
+	"fmt"
+	"strings"
+
+	tk "modernc.org/tk9.0"
+)
+
+var _ tk.Theme = (*StyleSet)(nil)
+
+// StyleSet is a set of b5 styles sharing a palette and image caches.
+//
+// The style methods of StyleSet, for example [StyleSet.EntryStyle], work like
+// the package level functions of the same name, but the colors passed to them
+// default to the palette of the set and the styles are remembered, so they
+// can be recreated by [StyleSet.Rebuild] when the palette or the display
+// scaling changes. Images no longer used after a rebuild are deleted.
+//
+// A StyleSet is registered in [tk.Themes] by [NewStyleSet] and can be
+// activated like any other registered theme. Activating it creates, if
+// necessary, a ttk theme of the same name inheriting from "clam" and rebuilds
+// the styles in it. Until then, the styles are created in the ttk theme
+// currently in use. The images of the styles are cached per ttk theme, so
+// rebuilding the styles in one ttk theme keeps the styles of the others
+// intact.
+type StyleSet struct {
+	background string
+	builder    *builder
+	builds     []func(*builder)
+	caches     map[string]*cache // ttk theme name: images of the styles in it
+	colors     Colors
+	gen        int
+	index      map[string]int // style name: index into builds
+	key        tk.ThemeKey
+	name       string
+	ttkTheme   string // ttk theme of builder
+}
+
+// NewStyleSet returns a new StyleSet named 'name' using the palette 'colors' on
+// windows with the 'background' color and registers it in [tk.Themes].
+func NewStyleSet(name string, colors Colors, background string) (r *StyleSet, err error) {
+	if name == "" || strings.ContainsAny(name, "{}\\") {
+		return nil, fmt.Errorf("b5: invalid theme name: %q", name)
+	}
+
+	r = &StyleSet{
+		background: background,
+		caches:     map[string]*cache{},
+		colors:     colors,
+		index:      map[string]int{},
+		name:       name,
+	}
+	if r.key, err = tk.RegisterTheme(name, r); err != nil {
+		return nil, err
+	}
+
+	if err = tk.SetThemeVariant(r.key, tk.ThemeVariant{Family: name, Dark: isDark(background)}); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// isDark reports whether 'color' is closer to black than to white.
+func isDark(color string) bool {
+	r, g, b, ok := rgb(color)
+	return ok && 299*r+587*g+114*b < 128*1000
+}
+
+// Key returns the key of 't' in [tk.Themes].
+func (t *StyleSet) Key() tk.ThemeKey {
+	return t.key
+}
+
+// Colors returns the palette of 't'.
+func (t *StyleSet) Colors() Colors {
+	return t.merge(nil)
+}
+
+// Background returns the window background color of 't'.
+func (t *StyleSet) Background() string {
+	return t.background
+}
+
+// SetColors sets the palette and the window background color of 't' and
+// rebuilds its styles.
+func (t *StyleSet) SetColors(colors Colors, background string) {
+	t.colors = colors
+	t.background = background
+	t.Rebuild()
+}
+
+// Rebuild recreates all styles of 't' in the current ttk theme using its
+// current palette and the current display scaling, see [tk.TkScaling]. The
+// images of the previous styles in the current ttk theme are deleted.
+func (t *StyleSet) Rebuild() {
+	nm := tk.StyleThemeUse()
+	old := t.caches[nm]
+	t.caches[nm] = newCache()
+	t.builder = nil
+	for _, v := range t.builds {
+		v(t.getBuilder())
+	}
+	if old != nil {
+		old.delete()
+	}
+}
+
+// getBuilder returns the builder of the current generation of styles in the
+// current ttk theme.
+func (t *StyleSet) getBuilder() *builder {
+	if nm := tk.StyleThemeUse(); t.builder == nil || t.ttkTheme != nm {
+		c := t.caches[nm]
+		if c == nil {
+			c = newCache()
+			t.caches[nm] = c
+		}
+		t.gen++
+		t.builder = newBuilder(c, fmt.Sprintf("b5g%d", t.gen))
+		t.ttkTheme = nm
+	}
+	return t.builder
+}
+
+// merge returns the theme palette overridden by 'colors'.
+func (t *StyleSet) merge(colors Colors) Colors {
+	r := Colors{}
+	for k, v := range t.colors {
+		r[k] = v
+	}
+	for k, v := range colors {
+		r[k] = v
+	}
+	return r
+}
+
+// add records the builder of 'style' and runs it.
+func (t *StyleSet) add(style string, f func(*builder)) string {
+	if i, ok := t.index[style]; ok {
+		t.builds[i] = f
+		t.Rebuild()
+		return style
+	}
+
+	t.index[style] = len(t.builds)
+	t.builds = append(t.builds, f)
+	f(t.getBuilder())
+	return style
+}
+
+// SizedButtonStyle is like [SizedButtonStyle].
+func (t *StyleSet) SizedButtonStyle(style string, colors Colors, size ButtonSize) string {
+	return t.add(style, func(b *builder) { b.sizedButtonStyle(style, t.merge(colors), t.background, size) })
+}
+
+// OutlineButtonStyle is like [OutlineButtonStyle].
+func (t *StyleSet) OutlineButtonStyle(style string, colors Colors, size ButtonSize) string {
+	return t.add(style, func(b *builder) { b.outlineButtonStyle(style, t.merge(colors), t.background, size) })
+}
+
+// EntryStyle is like [EntryStyle].
+func (t *StyleSet) EntryStyle(style string, colors Colors) string {
+	return t.add(style, func(b *builder) { b.entryStyle(style, t.merge(colors), t.background) })
+}
+
+// ComboboxStyle is like [ComboboxStyle].
+func (t *StyleSet) ComboboxStyle(style string, colors Colors) string {
+	return t.add(style, func(b *builder) { b.comboboxStyle(style, t.merge(colors), t.background) })
+}
+
+// BadgeStyle is like [BadgeStyle].
+func (t *StyleSet) BadgeStyle(style string, colors Colors) string {
+	return t.add(style, func(b *builder) { b.badgeStyle(style, t.merge(colors), t.background) })
+}
+
+// AlertStyle is like [AlertStyle].
+func (t *StyleSet) AlertStyle(name string, colors Colors) string {
+	return t.add(name, func(b *builder) { b.alertStyle(name, t.merge(colors), t.background) })
+}
+
+// CardStyle is like [CardStyle].
+func (t *StyleSet) CardStyle(name string, colors Colors) string {
+	return t.add(name, func(b *builder) { b.cardStyle(name, t.merge(colors), t.background) })
+}
+
+// ListGroupStyle is like [ListGroupStyle].
+func (t *StyleSet) ListGroupStyle(name string, colors Colors) string {
+	return t.add(name, func(b *builder) { b.listGroupStyle(name, t.merge(colors), t.background) })
+}
+
+// ProgressbarStyle is like [ProgressbarStyle].
+func (t *StyleSet) ProgressbarStyle(style string, colors Colors, striped bool) string {
+	return t.add(style, func(b *builder) { b.progressbarStyle(style, t.merge(colors), t.background, striped) })
+}
+
+// SwitchStyle is like [SwitchStyle].
+func (t *StyleSet) SwitchStyle(style string, colors Colors) string {
+	return t.add(style, func(b *builder) { b.switchStyle(style, t.merge(colors), t.background) })
+}
+
+// Initialize implements tk.Theme. It creates the ttk theme.
+func (t *StyleSet) Initialize(context tk.ThemeContext) error {
+	_, err := context.Eval(fmt.Sprintf("if {[lsearch -exact [ttk::style theme names] {%[1]s}] < 0} {ttk::style theme create {%[1]s} -parent clam}", t.name))
+	return err
+}
+
+// Activate implements tk.Theme. It makes the ttk theme current and rebuilds the
+// styles in it.
+func (t *StyleSet) Activate(context tk.ThemeContext) error {
+	if _, err := context.Eval(fmt.Sprintf("ttk::style theme use {%s}", t.name)); err != nil {
+		return err
+	}
+
+	t.Rebuild()
+	colors := t.merge(nil)
+	tk.StyleConfigure(".", tk.Background(t.background), tk.Foreground(colors.get(FieldText)),
+		tk.Selectbackground(colors.get(ButtonFace)), tk.Selectforeground(colors.get(ButtonText)))
+	return nil
+}
+
+// Deactivate implements tk.Theme.
+func (t *StyleSet) Deactivate(context tk.ThemeContext) error {
+	return nil
+}
+
+// Finalize implements tk.Theme. It deletes the style images.
+func (t *StyleSet) Finalize(context tk.ThemeContext) error {
+	for _, v := range t.caches {
+		v.delete()
+	}
+	return nil
+}
@@ -298,6 +298,18 @@
 // discoverable at run-time. Clients can use [ActivateTheme] to apply a theme
 // by name. Example in _examples/azure.go.
 //
+// Themes coming in light and dark variants can describe them using
+// [SetThemeVariant]. Applications can then switch between the variants of
+// the current theme using [ActivateThemeVariant] without knowing the theme
+// names. [OnThemeChanged] registers handlers called after the theme changes,
+// either by [ActivateTheme] or by [StyleThemeUse].
+//
+// Themes can be declared in Go, or loaded from JSON, using [ThemeSpec] and
+// registered by [RegisterThemeSpec]. Example in _examples/themespec.go.
+//
+// [ActivateThemeAuto] selects a light or dark theme according to the
+// desktop color scheme preference and follows its changes.
+//
 // # VNC server
 //
 // There is a VNC over wbesockets functionality available for X11 backed hosts.
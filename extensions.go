@@ -96,6 +96,21 @@ func (extensionContext) Collect(w *Window, options ...any) string {
 	return strings.Join(a, " ")
 }
 
+// ParseList splits the Tcl list 'list' into its elements.
+func ParseList(list string) []string {
+	return parseList(list)
+}
+
+// NewEventHandler returns an Opt which sets the widget option 'option', for
+// example "-editendcommand", to a command invoking 'handler'. The arguments
+// Tcl passes to the command are available via [Event.Args] and the command
+// returns [Event.Result]. The supported handler types are the same as in
+// [Command]. NewEventHandler is intended for extensions wrapping widgets with
+// callback options.
+func NewEventHandler(option string, handler any) Opt {
+	return newEventHandler(option, handler)
+}
+
 // Extension handles Tk extensions. When calling Extension methods registered
 // in Extension, the context argument is ignored and an instance is created
 // automatically.
@@ -157,12 +172,3 @@ func InitializeExtension(name string) (err error) {
 	}
 	return NotFound
 }
-
-func extensionInitialized(name string) bool {
-	for extName, ext := range Extensions {
-		if extName.Name == name && ext.(*extension).initialized {
-			return true
-		}
-	}
-	return false
-}
@@ -0,0 +1,75 @@
+// Copyright 2025 The tk9.0-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ctext // import "modernc.org/tk9.0/extensions/ctext"
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	. "modernc.org/tk9.0"
+)
+
+var (
+	tkOnce sync.Once
+	tkErr  error
+)
+
+// needTk skips the test if the extension cannot be initialized, for example
+// when there is no display.
+func needTk(t *testing.T) {
+	t.Helper()
+	tkOnce.Do(func() {
+		tkErr = Extensions[ExtensionKey{Type: "modernc.org/tk9.0/extensions/ctext.extension", Name: "ctext"}].Initialize(nil)
+	})
+	if tkErr != nil {
+		t.Skip(tkErr)
+	}
+}
+
+func TestAddTagRanges(t *testing.T) {
+	tags := map[string][]string{}
+	addTagRanges(tags, 12, "ab€cd", []Token{
+		{0, 2, "x"},
+		{2, 5, "y"}, // The 3 bytes of '€'.
+		{5, 7, "x"},
+		{7, 7, "z"}, // Empty.
+	})
+	if g, e := fmt.Sprint(tags), "map[x:[12.0 12.2 12.3 12.5] y:[12.2 12.3]]"; g != e {
+		t.Errorf("got %s, expected %s", g, e)
+	}
+
+	tags = map[string][]string{}
+	line := `s := "é" // c`
+	tokens, _ := goTokenizer(line, 0)
+	addTagRanges(tags, 3, line, tokens)
+	for k, e := range map[string]string{
+		ClassString:  "[3.5 3.8]",
+		ClassComment: "[3.9 3.13]",
+	} {
+		if g := fmt.Sprint(tags[k]); g != e {
+			t.Errorf("%s: got %s, expected %s", k, g, e)
+		}
+	}
+}
+
+func TestSetLanguageTwice(t *testing.T) {
+	needTk(t)
+	l := &Language{
+		Name:    "test",
+		Classes: []HighlightClass{{Name: "kw", Color: "blue", Keywords: []string{"$x", "[y]"}}},
+	}
+	RegisterLanguage(l)
+	w := Ctext()
+	defer Destroy(w)
+	for i := 0; i < 2; i++ {
+		if err := w.SetLanguage("test"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if g, e := fmt.Sprint(l.Classes[0].Keywords), "[$x [y]]"; g != e {
+		t.Errorf("got %s, expected %s", g, e)
+	}
+}
@@ -43,6 +43,14 @@ OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
 // See the [modernc.org/tk9.0.Extension] documentation for information about
 // initalizing extensions at runtime.
 //
+// # Languages
+//
+// [CtextWidget.SetLanguage] configures the syntax highlighting of a widget
+// using a language registered by [RegisterLanguage]. The package registers the
+// Go, JSON, Markdown, Python, SQL, Tcl and YAML languages. A language either
+// lists ctext highlight classes or provides a [Tokenizer], which re-highlights
+// only the edited lines.
+//
 // [modernc.org/tk9.0.Extension]: https://pkg.go.dev/modernc.org/tk9.0#Extension
 // [tklib ctext]: https://github.com/tcltk/tklib/tree/master/modules/ctext
 package ctext // import "modernc.org/tk9.0/extensions/ctext"
@@ -117,6 +125,9 @@ func (e *extension) Initialize(context ExtensionContext) error {
 type CtextWidget struct {
 	*Window
 	*TextWidget
+
+	language  *Language
+	tokenizer *tokenizer
 }
 
 func id() string {
@@ -0,0 +1,284 @@
+// Copyright 2025 The tk9.0-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ctext // import "modernc.org/tk9.0/extensions/ctext"
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	. "modernc.org/tk9.0"
+)
+
+var (
+	languages = map[string]*Language{}
+
+	tokenizerHookInstalled bool
+)
+
+// tokenizerHook routes the ctext highlighting of widgets with a tokenizer,
+// registered in ::ctext::goTokenizer, to Go. Edits are highlighted
+// immediately instead of at idle time, so no edited range is lost.
+const tokenizerHook = `
+rename ::ctext::highlight ::ctext::highlightClasses
+rename ::ctext::highlightAfterIdle ::ctext::highlightClassesAfterIdle
+proc ::ctext::highlight {win start end {afterTriggered 0}} {
+	if {![info exists ::ctext::goTokenizer($win)]} {
+		return [::ctext::highlightClasses $win $start $end $afterTriggered]
+	}
+
+	ctext::getAr $win config configAr
+	if {$afterTriggered} {
+		set configAr(highlightAfterId) ""
+	}
+	if {$configAr(-highlight)} {
+		{*}$::ctext::goTokenizer($win) [$win._t index $start] [$win._t index $end]
+	}
+}
+proc ::ctext::highlightAfterIdle {win lineStart lineEnd} {
+	if {![info exists ::ctext::goTokenizer($win)]} {
+		return [::ctext::highlightClassesAfterIdle $win $lineStart $lineEnd]
+	}
+
+	::ctext::highlight $win $lineStart $lineEnd
+}
+`
+
+// Language defines the syntax highlighting of a language, see
+// [RegisterLanguage] and [CtextWidget.SetLanguage].
+type Language struct {
+	// Name is the name the language is registered under.
+	Name string
+	// Classes are highlighted by ctext. Ignored when Tokenizer is not nil.
+	Classes []HighlightClass
+	// CComments enables the ctext highlighting of /* */ comments. Ignored
+	// when Tokenizer is not nil.
+	CComments bool
+	// Tokenizer, if not nil, highlights the text instead of Classes. Only the
+	// edited lines are tokenized again, and the lines following them while
+	// the tokenizer state at the end of a line changes.
+	Tokenizer Tokenizer
+	// Colors maps the token classes produced by Tokenizer to colors.
+	Colors map[string]string
+}
+
+// HighlightClass is a class of highlighted text. Exactly one of Keywords,
+// Regexp or SpecialChars should be set. See
+// [CtextWidget.AddHighlightClass],
+// [CtextWidget.AddHighlightClassForRegexp] and
+// [CtextWidget.AddHighlightClassForSpecialChars].
+type HighlightClass struct {
+	Name         string
+	Color        string
+	Keywords     []string
+	Regexp       string
+	SpecialChars string
+}
+
+// Token is a highlighted part of a line.
+type Token struct {
+	// Start and End are the byte offsets of the token in the line.
+	Start, End int
+	// Class selects the token color in Language.Colors.
+	Class string
+}
+
+// Tokenizer returns the tokens of 'line'. 'state' is the state returned for
+// the previous line, zero for the first line. The returned 'next' state is
+// passed to the tokenizer of the following line. It enables tokenizing
+// constructs spanning multiple lines, like block comments.
+type Tokenizer func(line string, state int) (tokens []Token, next int)
+
+// RegisterLanguage registers 'l' under l.Name, replacing any language of the
+// same name.
+func RegisterLanguage(l *Language) error {
+	if l == nil || l.Name == "" {
+		return fmt.Errorf("ctext: RegisterLanguage: missing name")
+	}
+
+	languages[l.Name] = l
+	return nil
+}
+
+// Languages returns the sorted names of the registered languages.
+func Languages() (r []string) {
+	for k := range languages {
+		r = append(r, k)
+	}
+	sort.Strings(r)
+	return r
+}
+
+// LookupLanguage returns the language registered as 'name' or nil.
+func LookupLanguage(name string) *Language {
+	return languages[name]
+}
+
+// SetLanguage replaces the highlighting of 'w' by the registered language
+// 'name' and highlights the whole text.
+func (w *CtextWidget) SetLanguage(name string) error {
+	l := languages[name]
+	if l == nil {
+		return fmt.Errorf("ctext: unknown language %q", name)
+	}
+
+	w.clearLanguage()
+	w.language = l
+	switch {
+	case l.Tokenizer != nil:
+		if !tokenizerHookInstalled {
+			ctx.EvalErr(tokenizerHook)
+			tokenizerHookInstalled = true
+		}
+		w.DisableComments()
+		for _, k := range l.classes() {
+			ctx.EvalErr(fmt.Sprintf("%s._t tag configure %s -foreground %s", w, ctx.TclSafeString(k), ctx.TclSafeString(l.Colors[k])))
+		}
+		w.tokenizer = &tokenizer{w: w, language: l}
+		ctx.EvalErr(fmt.Sprintf("set ::ctext::goTokenizer(%s) %s", w, strings.TrimSpace(ctx.Collect(w.Window, NewEventHandler("", w.tokenizer.highlight)))))
+	default:
+		// Tags created later have higher priority, create them in the
+		// order of the classes.
+		for _, v := range l.Classes {
+			ctx.EvalErr(fmt.Sprintf("%s._t tag configure %s -foreground %s", w, ctx.TclSafeString(v.Name), ctx.TclSafeString(v.Color)))
+		}
+		for _, v := range l.Classes {
+			switch {
+			case len(v.Keywords) != 0:
+				// AddHighlightClass escapes its arguments in place.
+				w.AddHighlightClass(v.Name, v.Color, slices.Clone(v.Keywords)...)
+			case v.Regexp != "":
+				w.AddHighlightClassForRegexp(v.Name, v.Color, ctx.TclSafeString(v.Regexp))
+			case v.SpecialChars != "":
+				w.AddHighlightClassForSpecialChars(v.Name, v.Color, v.SpecialChars)
+			}
+		}
+		if l.CComments {
+			w.EnableComments()
+		} else {
+			w.DisableComments()
+		}
+	}
+	ctx.EvalErr(fmt.Sprintf("%s highlight 1.0 end", w))
+	return nil
+}
+
+// clearLanguage removes the highlighting of the current language.
+func (w *CtextWidget) clearLanguage() {
+	l := w.language
+	if l == nil {
+		return
+	}
+
+	w.language = nil
+	w.tokenizer = nil
+	ctx.EvalErr(fmt.Sprintf("::ctext::clearHighlightClasses %s; unset -nocomplain ::ctext::goTokenizer(%[1]s)", w))
+	var tags []string
+	for _, v := range l.Classes {
+		tags = append(tags, ctx.TclSafeString(v.Name))
+	}
+	for k := range l.Colors {
+		tags = append(tags, ctx.TclSafeString(k))
+	}
+	if len(tags) != 0 {
+		ctx.EvalErr(fmt.Sprintf("%s._t tag delete %s", w, strings.Join(tags, " ")))
+	}
+}
+
+// classes returns the sorted token classes of l.Colors.
+func (l *Language) classes() (r []string) {
+	for k := range l.Colors {
+		r = append(r, k)
+	}
+	sort.Strings(r)
+	return r
+}
+
+// tokenizer highlights a ctext using a Language.Tokenizer.
+type tokenizer struct {
+	w        *CtextWidget
+	language *Language
+	states   []int // states[i] is the tokenizer state at the end of line i+1.
+}
+
+// highlight is invoked by ::ctext::highlight with the start and end indices
+// of the edited text.
+func (t *tokenizer) highlight(e *Event) {
+	args := e.Args()
+	if len(args) < 2 || t.w.tokenizer != t {
+		return
+	}
+
+	first, last := lineOf(args[0]), lineOf(args[1])
+	n := lineOf(ctx.EvalErr(fmt.Sprintf("%s._t index end", t.w))) - 1
+	first = max(1, min(first, n))
+	// Keep the states of the lines following the edit aligned with their
+	// lines.
+	at := min(first, len(t.states))
+	switch d := n - len(t.states); {
+	case d > 0:
+		t.states = append(t.states[:at], append(make([]int, d), t.states[at:]...)...)
+		last = max(last, first+d)
+	case d < 0:
+		t.states = append(t.states[:at], t.states[min(at-d, len(t.states)):]...)
+	}
+	last = max(first, min(last, n))
+	state := 0
+	if first > 1 {
+		state = t.states[first-2]
+	}
+	lines := strings.Split(ctx.EvalErr(fmt.Sprintf("%s._t get %d.0 %d.end", t.w, first, last)), "\n")
+	tags := map[string][]string{}
+	ln := first
+	for ; ln <= n; ln++ {
+		i := ln - first
+		if i >= len(lines) {
+			lines = append(lines, ctx.EvalErr(fmt.Sprintf("%s._t get %d.0 %[2]d.end", t.w, ln)))
+		}
+		line := lines[i]
+		tokens, next := t.language.Tokenizer(line, state)
+		addTagRanges(tags, ln, line, tokens)
+		old := t.states[ln-1]
+		t.states[ln-1] = next
+		state = next
+		if ln >= last && next == old {
+			break
+		}
+	}
+	var b strings.Builder
+	for _, k := range t.language.classes() {
+		fmt.Fprintf(&b, "%s._t tag remove %s %d.0 %d.end\n", t.w, ctx.TclSafeString(k), first, min(ln, n))
+	}
+	for k, v := range tags {
+		fmt.Fprintf(&b, "%s._t tag add %s %s\n", t.w, ctx.TclSafeString(k), strings.Join(v, " "))
+	}
+	ctx.EvalErr(b.String())
+}
+
+// addTagRanges adds to 'tags' the text index ranges of the non empty 'tokens'
+// of 'line', the content of line number 'ln', keyed by the token class.
+func addTagRanges(tags map[string][]string, ln int, line string, tokens []Token) {
+	for _, v := range tokens {
+		start, end := charOffset(line, v.Start), charOffset(line, v.End)
+		if end > start {
+			tags[v.Class] = append(tags[v.Class], fmt.Sprintf("%d.%d %[1]d.%[3]d", ln, start, end))
+		}
+	}
+}
+
+// lineOf returns the line number of the text index 'index'.
+func lineOf(index string) int {
+	s, _, _ := strings.Cut(index, ".")
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// charOffset converts the byte offset 'off' in 'line' to a character offset.
+func charOffset(line string, off int) int {
+	return utf8.RuneCountInString(line[:max(0, min(off, len(line)))])
+}
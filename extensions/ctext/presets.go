@@ -0,0 +1,195 @@
+// Copyright 2025 The tk9.0-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ctext // import "modernc.org/tk9.0/extensions/ctext"
+
+import (
+	"go/scanner"
+	"go/token"
+	"strings"
+)
+
+// Token classes of the built-in languages.
+const (
+	ClassBuiltin = "builtin"
+	ClassComment = "comment"
+	ClassHeading = "heading"
+	ClassKey     = "key"
+	ClassKeyword = "keyword"
+	ClassLink    = "link"
+	ClassNumber  = "number"
+	ClassString  = "string"
+	ClassVar     = "var"
+)
+
+// DefaultColors are the colors of the token classes of the built-in
+// languages.
+var DefaultColors = map[string]string{
+	ClassBuiltin: "#00627a",
+	ClassComment: "#8c8c8c",
+	ClassHeading: "#0033b3",
+	ClassKey:     "#871094",
+	ClassKeyword: "#0033b3",
+	ClassLink:    "#2a6099",
+	ClassNumber:  "#1750eb",
+	ClassString:  "#067d17",
+	ClassVar:     "#871094",
+}
+
+func init() {
+	for _, v := range []*Language{
+		{Name: "Go", Tokenizer: goTokenizer, Colors: DefaultColors},
+		{Name: "JSON", Classes: []HighlightClass{
+			class(ClassKeyword, "true", "false", "null"),
+			regexpClass(ClassNumber, `-?\m[0-9]+(\.[0-9]+)?([eE][-+]?[0-9]+)?`),
+			regexpClass(ClassString, `"([^"\\]|\\.)*"`),
+			regexpClass(ClassKey, `"([^"\\]|\\.)*"(?=\s*:)`),
+		}},
+		{Name: "Markdown", Classes: []HighlightClass{
+			regexpClass(ClassKeyword, `^\s*([-*+]|[0-9]+\.)\s`),
+			regexpClass(ClassString, "`[^`]*`"),
+			regexpClass(ClassLink, `\[[^\]]*\]\([^)]*\)`),
+			regexpClass(ClassBuiltin, `\*\*[^*]+\*\*|__[^_]+__`),
+			regexpClass(ClassHeading, `^#{1,6}\s.*$`),
+			regexpClass(ClassComment, `^\s*>.*$`),
+		}},
+		{Name: "Python", Classes: []HighlightClass{
+			class(ClassKeyword, "False", "None", "True", "and", "as", "assert", "async", "await", "break", "class", "continue", "def", "del", "elif", "else", "except", "finally", "for", "from", "global", "if", "import", "in", "is", "lambda", "nonlocal", "not", "or", "pass", "raise", "return", "try", "while", "with", "yield"),
+			class(ClassBuiltin, "abs", "all", "any", "bool", "bytes", "dict", "enumerate", "filter", "float", "int", "isinstance", "len", "list", "map", "max", "min", "object", "open", "print", "range", "repr", "self", "set", "sorted", "str", "sum", "super", "tuple", "type", "zip"),
+			regexpClass(ClassNumber, `\m[0-9][0-9_]*(\.[0-9_]*)?([eE][-+]?[0-9]+)?j?\M`),
+			regexpClass(ClassString, `"([^"\\]|\\.)*"|'([^'\\]|\\.)*'`),
+			regexpClass(ClassComment, `#.*$`),
+		}},
+		{Name: "SQL", Classes: []HighlightClass{
+			class(ClassKeyword, sqlKeywords()...),
+			regexpClass(ClassNumber, `\m[0-9]+(\.[0-9]+)?\M`),
+			regexpClass(ClassString, `'([^']|'')*'`),
+			regexpClass(ClassComment, `--.*$`),
+		}, CComments: true},
+		{Name: "Tcl", Classes: []HighlightClass{
+			class(ClassKeyword, "after", "append", "apply", "array", "break", "catch", "continue", "dict", "else", "elseif", "error", "eval", "expr", "for", "foreach", "format", "global", "if", "incr", "info", "lappend", "lassign", "lindex", "linsert", "list", "llength", "lmap", "lrange", "lreplace", "lsearch", "lset", "lsort", "namespace", "package", "proc", "puts", "regexp", "regsub", "rename", "return", "set", "source", "split", "string", "subst", "switch", "throw", "try", "unset", "upvar", "variable", "while"),
+			specialClass(ClassBuiltin, "{}[]"),
+			regexpClass(ClassVar, `\$(::)?[A-Za-z0-9_]+(::[A-Za-z0-9_]+)*`),
+			regexpClass(ClassString, `"([^"\\]|\\.)*"`),
+			regexpClass(ClassComment, `^\s*#.*$|;\s*#.*$`),
+		}},
+		{Name: "YAML", Classes: []HighlightClass{
+			class(ClassKeyword, "true", "false", "null", "yes", "no", "on", "off", "~"),
+			regexpClass(ClassNumber, `\m-?[0-9]+(\.[0-9]+)?\M`),
+			regexpClass(ClassKey, `^\s*(- )?[^\s:#][^:#]*:(?=\s|$)`),
+			regexpClass(ClassBuiltin, `^(---|\.\.\.)\s*$|[&*][A-Za-z0-9_-]+`),
+			regexpClass(ClassString, `"([^"\\]|\\.)*"|'([^']|'')*'`),
+			regexpClass(ClassComment, `(^|\s)#.*$`),
+		}},
+	} {
+		RegisterLanguage(v)
+	}
+}
+
+func class(name string, keywords ...string) HighlightClass {
+	return HighlightClass{Name: name, Color: DefaultColors[name], Keywords: keywords}
+}
+
+func regexpClass(name, re string) HighlightClass {
+	return HighlightClass{Name: name, Color: DefaultColors[name], Regexp: re}
+}
+
+func specialClass(name, chars string) HighlightClass {
+	return HighlightClass{Name: name, Color: DefaultColors[name], SpecialChars: chars}
+}
+
+// sqlKeywords returns the SQL keywords in upper and lower case, ctext matches
+// keywords case sensitively.
+func sqlKeywords() (r []string) {
+	for _, v := range strings.Fields(`
+ADD ALL ALTER AND AS ASC BEGIN BETWEEN BY CASE CHECK COLUMN COMMIT CONSTRAINT
+CREATE CROSS DEFAULT DELETE DESC DISTINCT DROP ELSE END EXISTS FOREIGN FROM
+FULL GROUP HAVING IF IN INDEX INNER INSERT INTO IS JOIN KEY LEFT LIKE LIMIT
+NOT NULL OFFSET ON OR ORDER OUTER PRIMARY REFERENCES RIGHT ROLLBACK SELECT SET
+TABLE THEN TRANSACTION UNION UNIQUE UPDATE VALUES VIEW WHEN WHERE WITH
+`) {
+		r = append(r, v, strings.ToLower(v))
+	}
+	return r
+}
+
+// States of goTokenizer.
+const (
+	goCode = iota
+	goBlockComment
+	goRawString
+)
+
+var goPredeclared = map[string]bool{}
+
+func init() {
+	for _, v := range strings.Fields(`
+any append bool byte cap clear close comparable complex complex128 complex64
+copy delete error false float32 float64 imag int int16 int32 int64 int8 iota
+len make max min new nil panic print println real recover rune string true
+uint uint16 uint32 uint64 uint8 uintptr
+`) {
+		goPredeclared[v] = true
+	}
+}
+
+// goTokenizer is the Tokenizer of the "Go" language.
+func goTokenizer(line string, state int) (tokens []Token, next int) {
+	off := 0
+	switch state {
+	case goBlockComment, goRawString:
+		delim := "*/"
+		class := ClassComment
+		if state == goRawString {
+			delim = "`"
+			class = ClassString
+		}
+		i := strings.Index(line, delim)
+		if i < 0 {
+			return []Token{{0, len(line), class}}, state
+		}
+
+		off = i + len(delim)
+		tokens = append(tokens, Token{0, off, class})
+	}
+
+	src := []byte(line[off:])
+	file := token.NewFileSet().AddFile("", -1, len(src))
+	var s scanner.Scanner
+	next = goCode
+	s.Init(file, src, func(pos token.Position, msg string) {
+		switch msg {
+		case "comment not terminated":
+			next = goBlockComment
+		case "raw string literal not terminated":
+			next = goRawString
+		}
+	}, scanner.ScanComments)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+
+		var class string
+		switch {
+		case tok.IsKeyword():
+			class = ClassKeyword
+		case tok == token.COMMENT:
+			class = ClassComment
+		case tok == token.STRING || tok == token.CHAR:
+			class = ClassString
+		case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+			class = ClassNumber
+		case tok == token.IDENT && goPredeclared[lit]:
+			class = ClassBuiltin
+		default:
+			continue
+		}
+
+		start := off + file.Offset(pos)
+		tokens = append(tokens, Token{start, start + len(lit), class})
+	}
+	return tokens, next
+}
@@ -0,0 +1,63 @@
+// Copyright 2025 The tk9.0-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package editor // import "modernc.org/tk9.0/extensions/editor"
+
+import (
+	"errors"
+	"testing"
+
+	. "modernc.org/tk9.0"
+)
+
+func TestIndentation(t *testing.T) {
+	for i, v := range []struct {
+		s string
+		e int
+	}{
+		{"", 0},
+		{"x", 0},
+		{"  x", 2},
+		{"\tx", 8},
+		{"  \tx", 8},
+		{"\t  x", 10},
+		{"\t\t", 16},
+		{"        \tx", 16},
+	} {
+		if g, e := indentation(v.s), v.e; g != e {
+			t.Errorf("%v: %q: got %v, expected %v", i, v.s, g, e)
+		}
+	}
+}
+
+func TestFoldLength(t *testing.T) {
+	for i, v := range []struct {
+		lines []string
+		e     int
+	}{
+		{nil, 0},
+		{[]string{""}, 0},
+		{[]string{"   ", "\tx"}, 0},
+		{[]string{"func f() {", "}"}, 0},
+		{[]string{"func f() {", "\tx", "}"}, 1},
+		{[]string{"func f() {", "\tx", "", "\t\ty", "}"}, 3},
+		{[]string{"\tif x {", "\t\ty", "", ""}, 1},
+		{[]string{"\tif x {", "\t\ty", "\t}", "\tz"}, 1},
+		{[]string{"a", "  b", "\tc"}, 2},
+	} {
+		if g, e := foldLength(v.lines), v.e; g != e {
+			t.Errorf("%v: %q: got %v, expected %v", i, v.lines, g, e)
+		}
+	}
+}
+
+func TestEditorNotInitialized(t *testing.T) {
+	defer func() {
+		if err, ok := recover().(error); !ok || !errors.Is(err, NotInitialized) {
+			t.Errorf("got %v, expected a NotInitialized error", err)
+		}
+	}()
+
+	Editor()
+}
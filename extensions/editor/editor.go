@@ -0,0 +1,824 @@
+// Copyright 2025 The tk9.0-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package editor provides a code editor widget built on [ctext].
+//
+// To make the extension available in an application:
+//
+//	import "modernc.org/tk9.0/extension/editor"
+//
+// The ctext extension must be initialized before the editor extension:
+//
+//	InitializeExtension("ctext")
+//	InitializeExtension("editor")
+//
+// See the [modernc.org/tk9.0.Extension] documentation for information about
+// initalizing extensions at runtime.
+//
+// [EditorWidget] adds to [ctext.CtextWidget] a completion popup fed by a
+// [CompletionProvider], matching bracket highlighting, code folding, gutter
+// markers with tooltips, indenting and outdenting of the selected lines and
+// go-to-line. The key bindings are
+//
+//	Control-space      open the completion popup
+//	Up, Down           select a completion
+//	Return, Tab        insert the selected completion
+//	Escape             close the completion popup
+//	Tab                indent the selected lines, if the selection spans lines
+//	Shift-Tab          outdent the selected lines or the current line
+//	Control-g          prompt for a line number and go to it
+//
+// Gutter markers color the line numbers of the ctext line map, they are not
+// visible when the line map is disabled.
+//
+// [modernc.org/tk9.0.Extension]: https://pkg.go.dev/modernc.org/tk9.0#Extension
+package editor // import "modernc.org/tk9.0/extensions/editor"
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	. "modernc.org/tk9.0"
+	"modernc.org/tk9.0/extensions/ctext"
+)
+
+var (
+	_ Extension = (*extension)(nil)
+
+	ctx         ExtensionContext
+	id0         int
+	initialized bool
+
+	markerKinds = map[string]markerKind{
+		"breakpoint": {"#e51400", "#ffffff"},
+		"error":      {"#f8d7da", "#842029"},
+		"fold":       {"#e9ecef", "#495057"},
+		"info":       {"#cfe2ff", "#052c65"},
+		"warning":    {"#fff3cd", "#664d03"},
+	}
+)
+
+// editorTcl hooks the ctext line map updates and implements bracket matching.
+const editorTcl = `
+namespace eval ::editor {}
+rename ::ctext::linemapUpdate ::editor::linemapUpdate
+proc ::ctext::linemapUpdate {win args} {
+	::editor::linemapUpdate $win {*}$args
+	if {[info exists ::editor::gutter($win)] && [winfo exists $win.l]} {
+		{*}$::editor::gutter($win)
+	}
+}
+proc ::editor::skip {t index} {
+	foreach tag [$t tag names $index] {
+		if {$tag in {comment string _cComment}} {
+			return 1
+		}
+	}
+	return 0
+}
+proc ::editor::match {t index} {
+	set c [$t get $index]
+	set open "(\[\{"
+	set close ")\]\}"
+	if {[set i [string first $c $open]] >= 0} {
+		set other [string index $close $i]
+		set dir -forwards
+		set start "$index + 1c"
+		set stop end
+	} elseif {[set i [string first $c $close]] >= 0} {
+		set other [string index $open $i]
+		set dir -backwards
+		set start $index
+		set stop 1.0
+	} else {
+		return
+	}
+
+	set skip [expr {![::editor::skip $t $index]}]
+	set re "\[\\$c\\$other\]"
+	set depth 1
+	while {[set pos [$t search $dir -regexp -- $re $start $stop]] ne ""} {
+		if {$dir eq "-forwards"} {
+			set start "$pos + 1c"
+		} else {
+			set start $pos
+		}
+		if {$skip && [::editor::skip $t $pos]} {
+			continue
+		}
+
+		if {[$t get $pos] eq $c} {
+			incr depth
+		} elseif {[incr depth -1] == 0} {
+			return [$t index $pos]
+		}
+	}
+}
+proc ::editor::highlightBracket {win} {
+	set t $win._t
+	$t tag remove editor:bracket 1.0 end
+	foreach index {"insert - 1c" insert} {
+		if {[set pos [::editor::match $t $index]] ne ""} {
+			$t tag add editor:bracket $index $pos
+			return
+		}
+	}
+}
+`
+
+func init() {
+	RegisterExtension("editor", newExtension())
+}
+
+type extension struct{}
+
+func newExtension() *extension {
+	return &extension{}
+}
+
+func (e *extension) Initialize(context ExtensionContext) (err error) {
+	defer func() {
+		initialized = true
+	}()
+
+	if initialized {
+		return nil
+	}
+
+	ctx = context // initialize the "global" context
+	if _, err = ctx.Eval("package present ctext"); err != nil {
+		return fmt.Errorf("editor: the ctext extension must be initialized first")
+	}
+
+	_, err = ctx.Eval(editorTcl)
+	return err
+}
+
+// Completion is an item of the completion popup.
+type Completion struct {
+	// Label is shown in the popup.
+	Label string
+	// Text replaces the completed prefix. Label is used when Text is empty.
+	Text string
+}
+
+// CompletionRequest describes the position where completion was requested.
+type CompletionRequest struct {
+	// Text is the editor content.
+	Text string
+	// Line is the 1-based line of the insertion cursor.
+	Line int
+	// Column is the 0-based character column of the insertion cursor.
+	Column int
+	// Prefix is the identifier immediately before the insertion cursor,
+	// possibly empty. Accepting a completion replaces it.
+	Prefix string
+}
+
+// CompletionProvider supplies the items of the completion popup.
+type CompletionProvider interface {
+	Completions(req CompletionRequest) []Completion
+}
+
+// CompletionFunc adapts a function to [CompletionProvider].
+type CompletionFunc func(req CompletionRequest) []Completion
+
+// Completions implements [CompletionProvider].
+func (f CompletionFunc) Completions(req CompletionRequest) []Completion {
+	return f(req)
+}
+
+// MarkerID identifies a gutter marker.
+type MarkerID int
+
+// Marker is a gutter marker.
+type Marker struct {
+	ID MarkerID
+	// Line is the current line of the marker. Markers move with the text
+	// when lines are inserted or deleted above them.
+	Line int
+	// Kind selects the marker colors, see [DefineMarker].
+	Kind string
+	// Tooltip is shown when the mouse hovers over the marker. Can be empty.
+	Tooltip string
+}
+
+type markerKind struct {
+	background, foreground string
+}
+
+// DefineMarker defines or redefines the gutter marker 'kind'. The line numbers
+// of marked lines are drawn using the 'foreground' and 'background' colors.
+// The predefined kinds are "breakpoint", "error", "warning", "info" and
+// "fold".
+func DefineMarker(kind, background, foreground string) {
+	markerKinds[kind] = markerKind{background, foreground}
+}
+
+// EditorWidget is a ctext widget with code editing support. It has all the
+// methods of [ctext.CtextWidget], like [ctext.CtextWidget.SetLanguage].
+type EditorWidget struct {
+	*ctext.CtextWidget
+
+	completions []Completion
+	folds       map[string]MarkerID // tag: marker
+	gutterClick []func(line int)
+	indent      string
+	markers     map[MarkerID]*Marker
+	minPrefix   int
+	popup       bool
+	provider    CompletionProvider
+}
+
+// Editor returns a newly created EditorWidget. Pass the parent *Window as the
+// first argument to make the widget parented. The other options are passed to
+// [ctext.Ctext].
+//
+// Editor panics if the editor extension was not initialized, see
+// [modernc.org/tk9.0.InitializeExtension].
+func Editor(options ...any) (r *EditorWidget) {
+	if ctx == nil {
+		panic(fmt.Errorf("editor: extension %w", NotInitialized))
+	}
+
+	r = &EditorWidget{
+		CtextWidget: ctext.Ctext(options...),
+		folds:       map[string]MarkerID{},
+		indent:      "\t",
+		markers:     map[MarkerID]*Marker{},
+	}
+	t := r.t()
+	ctx.EvalErr(fmt.Sprintf(`
+%[2]s tag configure editor:bracket -background #c8e1ff
+%[2]s tag raise editor:bracket
+set ::editor::gutter(%[1]s) {%[3]s}
+bind %[1]s.t <KeyRelease> {+%[4]s %%K}
+bind %[1]s.t <ButtonRelease-1> {+::editor::highlightBracket %[1]s}
+bind %[1]s.t <ButtonPress-1> {+%[5]s}
+bind %[1]s.t <Control-space> {%[6]s; break}
+bind %[1]s.t <Control-g> {%[7]s; break}
+bind %[1]s.l <ButtonPress-1> {+%[8]s %%y}
+bind %[1]s.l <Motion> {%[9]s %%y %%X %%Y}
+bind %[1]s.l <Leave> {%[10]s}
+`,
+		r, t,
+		r.command(r.gutter),
+		r.command(r.keyRelease),
+		r.command(func() { r.hideCompletions() }),
+		r.command(func() { r.Complete() }),
+		r.command(func() { r.GotoLinePrompt() }),
+		r.command(r.gutterPress),
+		r.command(r.gutterMotion),
+		r.command(func() { r.hideTooltip() }),
+	))
+	for _, v := range []string{"Up", "Down", "Return", "Tab", "Escape", "Shift-Tab"} {
+		ctx.EvalErr(fmt.Sprintf("bind %s.t <%s> {%s %s}", r, v, r.command(r.keyPress), v))
+	}
+	ctx.Eval(fmt.Sprintf("bind %s.t <ISO_Left_Tab> {%s Shift-Tab}", r, r.command(r.keyPress)))
+	return r
+}
+
+// command returns a Tcl command invoking 'handler'.
+func (w *EditorWidget) command(handler any) string {
+	s := strings.TrimSpace(ctx.Collect(w.Window, NewEventHandler("", handler)))
+	return strings.TrimSuffix(strings.TrimPrefix(s, "{"), "}")
+}
+
+// t returns the Tcl command of the inner text widget. Unlike the ctext
+// command, it does not trigger highlighting.
+func (w *EditorWidget) t() string {
+	return w.String() + "._t"
+}
+
+// index returns the line and character column of 'index'.
+func (w *EditorWidget) index(index string) (line, col int) {
+	fmt.Sscanf(ctx.EvalErr(fmt.Sprintf("%s index %s", w.t(), ctx.TclSafeString(index))), "%d.%d", &line, &col)
+	return line, col
+}
+
+// lastLine returns the number of the last line.
+func (w *EditorWidget) lastLine() int {
+	n, _ := w.index("end - 1c")
+	return n
+}
+
+func (w *EditorWidget) keyRelease(e *Event) {
+	var keysym string
+	if a := e.Args(); len(a) != 0 {
+		keysym = a[0]
+	}
+	ctx.EvalErr(fmt.Sprintf("::editor::highlightBracket %s", w))
+	switch keysym {
+	case "Up", "Down", "Return", "Tab", "Escape", "Shift_L", "Shift_R", "Control_L", "Control_R", "space":
+		return
+	}
+
+	switch {
+	case w.popup:
+		w.Complete()
+	case w.minPrefix > 0 && w.provider != nil && len([]rune(w.prefix())) >= w.minPrefix:
+		w.Complete()
+	}
+}
+
+func (w *EditorWidget) keyPress(e *Event) {
+	var key string
+	if a := e.Args(); len(a) != 0 {
+		key = a[0]
+	}
+	if w.popup {
+		switch key {
+		case "Up":
+			w.moveSelection(-1)
+		case "Down":
+			w.moveSelection(1)
+		case "Return", "Tab":
+			w.acceptCompletion()
+		case "Escape":
+			w.hideCompletions()
+		default:
+			return
+		}
+
+		e.SetReturnCodeBreak()
+		return
+	}
+
+	switch key {
+	case "Tab":
+		first, last, ok := w.selectedLines()
+		if !ok || first == last {
+			return
+		}
+
+		w.Indent(first, last)
+	case "Shift-Tab":
+		first, last, ok := w.selectedLines()
+		if !ok {
+			first, _ = w.index("insert")
+			last = first
+		}
+		w.Outdent(first, last)
+	default:
+		return
+	}
+
+	e.SetReturnCodeBreak()
+}
+
+// selectedLines returns the lines spanned by the selection. A selection
+// ending at the start of a line does not include that line.
+func (w *EditorWidget) selectedLines() (first, last int, ok bool) {
+	r := ParseList(ctx.EvalErr(fmt.Sprintf("%s tag ranges sel", w.t())))
+	if len(r) < 2 {
+		return 0, 0, false
+	}
+
+	first, _ = w.index(r[0])
+	last, col := w.index(r[len(r)-1])
+	if col == 0 && last > first {
+		last--
+	}
+	return first, last, true
+}
+
+// SetCompletionProvider sets the source of completions. If 'minPrefix' is
+// positive, the completion popup opens automatically when the identifier
+// before the insertion cursor has at least 'minPrefix' characters. Otherwise
+// it opens only on Control-space or when [EditorWidget.Complete] is called.
+func (w *EditorWidget) SetCompletionProvider(p CompletionProvider, minPrefix int) {
+	w.provider = p
+	w.minPrefix = minPrefix
+}
+
+// prefix returns the identifier immediately before the insertion cursor.
+func (w *EditorWidget) prefix() string {
+	s := []rune(ctx.EvalErr(fmt.Sprintf("%s get {insert linestart} insert", w.t())))
+	i := len(s)
+	for i > 0 && (s[i-1] == '_' || unicode.IsLetter(s[i-1]) || unicode.IsDigit(s[i-1])) {
+		i--
+	}
+	return string(s[i:])
+}
+
+// Complete queries the completion provider and shows its results in the
+// completion popup. The popup is closed if there are no results.
+func (w *EditorWidget) Complete() {
+	if w.provider == nil {
+		return
+	}
+
+	line, col := w.index("insert")
+	w.completions = w.provider.Completions(CompletionRequest{
+		Text:   ctx.EvalErr(fmt.Sprintf("%s get 1.0 {end - 1c}", w.t())),
+		Line:   line,
+		Column: col,
+		Prefix: w.prefix(),
+	})
+	if len(w.completions) == 0 {
+		w.hideCompletions()
+		return
+	}
+
+	var items []string
+	for _, v := range w.completions {
+		items = append(items, ctx.TclSafeString(v.Label))
+	}
+	ctx.EvalErr(fmt.Sprintf(`
+set top %[1]s.complete
+if {![winfo exists $top]} {
+	toplevel $top -borderwidth 1 -relief solid
+	wm withdraw $top
+	wm overrideredirect $top 1
+	listbox $top.l -height 8 -exportselection 0 -activestyle none -takefocus 0 -highlightthickness 0 -borderwidth 0
+	pack $top.l -fill both -expand 1
+	bind $top.l <Double-1> {%[2]s}
+}
+$top.l delete 0 end
+$top.l insert end %[3]s
+$top.l configure -height [expr {min(8, [$top.l size])}]
+$top.l selection set 0
+$top.l see 0
+lassign [%[4]s bbox insert] x y w h
+if {$x ne ""} {
+	wm geometry $top +[expr {[winfo rootx %[4]s] + $x}]+[expr {[winfo rooty %[4]s] + $y + $h}]
+}
+wm deiconify $top
+raise $top
+`, w, w.command(func() { w.acceptCompletion() }), strings.Join(items, " "), w.String()+".t"))
+	w.popup = true
+}
+
+func (w *EditorWidget) hideCompletions() {
+	if !w.popup {
+		return
+	}
+
+	w.popup = false
+	ctx.EvalErr(fmt.Sprintf("wm withdraw %s.complete", w))
+}
+
+// moveSelection moves the selection in the completion popup by 'delta' items.
+func (w *EditorWidget) moveSelection(delta int) {
+	ctx.EvalErr(fmt.Sprintf(`
+set l %s.complete.l
+set i [expr {([lindex [$l curselection] 0] + %d + [$l size]) %% [$l size]}]
+$l selection clear 0 end
+$l selection set $i
+$l see $i
+`, w, delta))
+}
+
+// acceptCompletion replaces the prefix before the insertion cursor by the
+// selected completion.
+func (w *EditorWidget) acceptCompletion() {
+	var i int
+	if _, err := fmt.Sscan(ctx.EvalErr(fmt.Sprintf("lindex [%s.complete.l curselection] 0", w)), &i); err != nil || i >= len(w.completions) {
+		w.hideCompletions()
+		return
+	}
+
+	c := w.completions[i]
+	s := c.Text
+	if s == "" {
+		s = c.Label
+	}
+	n := len([]rune(w.prefix()))
+	w.hideCompletions()
+	ctx.EvalErr(fmt.Sprintf("%[1]s delete {insert - %[2]d chars} insert; %[1]s insert insert %[3]s; %[1]s see insert", w, n, ctx.TclSafeString(s)))
+}
+
+// MatchingBracket returns the index of the bracket matching the one at
+// 'index' or "" if there is none. Brackets in comments and strings are
+// ignored unless the one at 'index' is in a comment or string.
+func (w *EditorWidget) MatchingBracket(index any) string {
+	return ctx.EvalErr(fmt.Sprintf("::editor::match %s %s", w.t(), ctx.TclSafeString(fmt.Sprint(index))))
+}
+
+// SetIndent sets the string inserted by [EditorWidget.Indent]. The default is
+// a tab.
+func (w *EditorWidget) SetIndent(s string) {
+	w.indent = s
+}
+
+// Indent indents the lines 'first' to 'last'. Empty lines are not changed.
+func (w *EditorWidget) Indent(first, last int) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s edit separator\n", w.t())
+	for i := first; i <= last; i++ {
+		fmt.Fprintf(&b, "if {[%[1]s get %[2]d.0 %[2]d.end] ne {}} {%[3]s insert %[2]d.0 %[4]s}\n", w.t(), i, w, ctx.TclSafeString(w.indent))
+	}
+	fmt.Fprintf(&b, "%s edit separator\n", w.t())
+	ctx.EvalErr(b.String())
+}
+
+// Outdent removes one level of indentation from the lines 'first' to 'last'.
+// A level is a tab or up to as many spaces as the indent string is wide.
+func (w *EditorWidget) Outdent(first, last int) {
+	width := 0
+	for _, c := range w.indent {
+		switch c {
+		case '\t':
+			width += 8
+		default:
+			width++
+		}
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s edit separator\n", w.t())
+	for i := first; i <= last; i++ {
+		s := ctx.EvalErr(fmt.Sprintf("%s get %d.0 %[2]d.end", w.t(), i))
+		n := 0
+		switch {
+		case strings.HasPrefix(s, "\t"):
+			n = 1
+		default:
+			for n < len(s) && n < width && s[n] == ' ' {
+				n++
+			}
+		}
+		if n != 0 {
+			fmt.Fprintf(&b, "%s delete %d.0 %[2]d.%d\n", w, i, n)
+		}
+	}
+	fmt.Fprintf(&b, "%s edit separator\n", w.t())
+	ctx.EvalErr(b.String())
+}
+
+// GotoLine moves the insertion cursor to the start of 'line', scrolls it into
+// view and unfolds it if it is folded.
+func (w *EditorWidget) GotoLine(line int) {
+	line = max(1, min(line, w.lastLine()))
+	for tag, id := range w.folds {
+		if first, last, ok := w.foldLines(tag); ok && line > first && line <= last {
+			w.unfold(tag, id)
+		}
+	}
+	ctx.EvalErr(fmt.Sprintf("%[1]s mark set insert %[2]d.0; %[1]s tag remove sel 1.0 end; %[1]s see insert; focus %[3]s.t", w.t(), line, w))
+	ctx.EvalErr(fmt.Sprintf("::editor::highlightBracket %s", w))
+}
+
+// GotoLinePrompt shows an entry in the top right corner of the editor and goes
+// to the line entered. Escape closes the entry.
+func (w *EditorWidget) GotoLinePrompt() {
+	ctx.EvalErr(fmt.Sprintf(`
+set e %[1]s.goto
+destroy $e
+ttk::entry $e -width 8
+place $e -relx 1 -x -4 -y 4 -anchor ne
+bind $e <Return> {%[2]s [%[1]s.goto get]; destroy %[1]s.goto}
+bind $e <Escape> {destroy %[1]s.goto; focus %[1]s.t}
+bind $e <FocusOut> {destroy %[1]s.goto}
+focus $e
+`, w, w.command(func(e *Event) {
+		var n int
+		if a := e.Args(); len(a) != 0 {
+			if _, err := fmt.Sscan(a[0], &n); err == nil {
+				w.GotoLine(n)
+			}
+		}
+	})))
+}
+
+// Fold hides the lines 'first'+1 to 'last'. Line 'first' remains visible and
+// gets a "fold" gutter marker.
+func (w *EditorWidget) Fold(first, last int) {
+	last = min(last, w.lastLine())
+	if first < 1 || last <= first {
+		return
+	}
+
+	id0++
+	tag := fmt.Sprintf("editor:fold:%d", id0)
+	ctx.EvalErr(fmt.Sprintf("%[1]s tag configure %[2]s -elide 1; %[1]s tag add %[2]s %[3]d.end %[4]d.end", w.t(), tag, first, last))
+	w.folds[tag] = w.AddMarker(first, "fold", fmt.Sprintf("%d lines folded", last-first))
+}
+
+// Unfold shows the lines hidden by the fold starting at 'line'. It reports
+// whether there was such fold.
+func (w *EditorWidget) Unfold(line int) bool {
+	for tag, id := range w.folds {
+		if first, _, ok := w.foldLines(tag); ok && first == line {
+			w.unfold(tag, id)
+			return true
+		}
+	}
+	return false
+}
+
+// UnfoldAll removes all folds.
+func (w *EditorWidget) UnfoldAll() {
+	for tag, id := range w.folds {
+		w.unfold(tag, id)
+	}
+}
+
+// ToggleFold unfolds the fold starting at 'line' or, if there is none, folds
+// the block of lines following 'line' that are indented more than 'line'.
+func (w *EditorWidget) ToggleFold(line int) {
+	if w.Unfold(line) {
+		return
+	}
+
+	lines := strings.Split(ctx.EvalErr(fmt.Sprintf("%s get %d.0 {end - 1c}", w.t(), line)), "\n")
+	w.Fold(line, line+foldLength(lines))
+}
+
+// foldLength returns the number of 'lines' following lines[0] that are
+// indented more than lines[0]. Blank lines inside the block are included,
+// trailing blank lines are not. It returns zero if lines[0] is blank.
+func foldLength(lines []string) (r int) {
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) == "" {
+		return 0
+	}
+
+	level := indentation(lines[0])
+	for i, v := range lines[1:] {
+		if strings.TrimSpace(v) == "" {
+			continue
+		}
+
+		if indentation(v) <= level {
+			break
+		}
+
+		r = i + 1
+	}
+	return r
+}
+
+func (w *EditorWidget) unfold(tag string, id MarkerID) {
+	delete(w.folds, tag)
+	ctx.EvalErr(fmt.Sprintf("%s tag delete %s", w.t(), tag))
+	w.RemoveMarker(id)
+}
+
+// foldLines returns the first and last line of the fold 'tag'.
+func (w *EditorWidget) foldLines(tag string) (first, last int, ok bool) {
+	r := ParseList(ctx.EvalErr(fmt.Sprintf("%s tag ranges %s", w.t(), tag)))
+	if len(r) < 2 {
+		return 0, 0, false
+	}
+
+	first, _ = w.index(r[0])
+	last, _ = w.index(r[len(r)-1])
+	return first, last, true
+}
+
+// indentation returns the width of the leading white space of 's'.
+func indentation(s string) (r int) {
+	for _, c := range s {
+		switch c {
+		case ' ':
+			r++
+		case '\t':
+			r += 8 - r%8
+		default:
+			return r
+		}
+	}
+	return r
+}
+
+// AddMarker adds a gutter marker of 'kind' to 'line'. The 'tooltip' is shown
+// when the mouse hovers over the marker.
+func (w *EditorWidget) AddMarker(line int, kind, tooltip string) MarkerID {
+	id0++
+	id := MarkerID(id0)
+	w.markers[id] = &Marker{ID: id, Kind: kind, Tooltip: tooltip}
+	ctx.EvalErr(fmt.Sprintf("%[1]s mark set editor:marker:%[2]d %[3]d.0; %[1]s mark gravity editor:marker:%[2]d left", w.t(), id, line))
+	w.updateGutter()
+	return id
+}
+
+// RemoveMarker removes the gutter marker 'id'.
+func (w *EditorWidget) RemoveMarker(id MarkerID) {
+	if _, ok := w.markers[id]; !ok {
+		return
+	}
+
+	delete(w.markers, id)
+	ctx.EvalErr(fmt.Sprintf("%s mark unset editor:marker:%d", w.t(), id))
+	w.updateGutter()
+}
+
+// ClearMarkers removes all gutter markers of 'kind', or all markers if 'kind'
+// is empty. Fold markers are removed by unfolding.
+func (w *EditorWidget) ClearMarkers(kind string) {
+	for id, m := range w.markers {
+		if m.Kind != "fold" && (kind == "" || m.Kind == kind) {
+			delete(w.markers, id)
+			ctx.EvalErr(fmt.Sprintf("%s mark unset editor:marker:%d", w.t(), id))
+		}
+	}
+	w.updateGutter()
+}
+
+// Markers returns the gutter markers with their current lines.
+func (w *EditorWidget) Markers() (r []Marker) {
+	for id, m := range w.markers {
+		m.Line, _ = w.index(fmt.Sprintf("editor:marker:%d", id))
+		r = append(r, *m)
+	}
+	return r
+}
+
+// OnGutterClick registers 'handler' to be called with the line number when
+// the gutter is clicked. It can be used, for example, to toggle breakpoints.
+func (w *EditorWidget) OnGutterClick(handler func(line int)) {
+	w.gutterClick = append(w.gutterClick, handler)
+}
+
+// markerAt returns the markers at 'line'.
+func (w *EditorWidget) markerAt(line int) (r []Marker) {
+	for _, m := range w.Markers() {
+		if m.Line == line {
+			r = append(r, m)
+		}
+	}
+	return r
+}
+
+// lineAt returns the text line shown at the gutter 'y' coordinate.
+func (w *EditorWidget) lineAt(y string) int {
+	line, _ := w.index("@0," + y)
+	return line
+}
+
+func (w *EditorWidget) updateGutter() {
+	ctx.EvalErr(fmt.Sprintf("::ctext::linemapUpdate %s", w))
+}
+
+// gutter colors the line numbers of marked lines. Invoked after every line
+// map update.
+func (w *EditorWidget) gutter() {
+	if len(w.markers) == 0 {
+		return
+	}
+
+	byLine := map[string]Marker{}
+	for _, m := range w.Markers() {
+		k := fmt.Sprint(m.Line)
+		// Prefer markers other than "fold".
+		if o, ok := byLine[k]; !ok || o.Kind == "fold" {
+			byLine[k] = m
+		}
+	}
+	var b strings.Builder
+	for k, v := range markerKinds {
+		fmt.Fprintf(&b, "%s.l tag configure %s -background %s -foreground %s\n", w, ctx.TclSafeString("editor:"+k), ctx.TclSafeString(v.background), ctx.TclSafeString(v.foreground))
+	}
+	for i, v := range strings.Split(ctx.EvalErr(fmt.Sprintf("%s.l get 1.0 {end - 1c}", w)), "\n") {
+		if m, ok := byLine[strings.TrimSpace(v)]; ok {
+			fmt.Fprintf(&b, "%s.l tag add %s %d.0 %[3]d.end\n", w, ctx.TclSafeString("editor:"+m.Kind), i+1)
+		}
+	}
+	ctx.EvalErr(b.String())
+}
+
+func (w *EditorWidget) gutterPress(e *Event) {
+	if a := e.Args(); len(a) != 0 {
+		line := w.lineAt(a[0])
+		for _, f := range w.gutterClick {
+			f(line)
+		}
+	}
+}
+
+func (w *EditorWidget) gutterMotion(e *Event) {
+	a := e.Args()
+	if len(a) < 3 {
+		return
+	}
+
+	var tips []string
+	for _, m := range w.markerAt(w.lineAt(a[0])) {
+		if m.Tooltip != "" {
+			tips = append(tips, m.Tooltip)
+		}
+	}
+	if len(tips) == 0 {
+		w.hideTooltip()
+		return
+	}
+
+	ctx.EvalErr(fmt.Sprintf(`
+set tip %[1]s.tip
+if {![winfo exists $tip]} {
+	toplevel $tip -borderwidth 1 -relief solid -background #ffffe0
+	wm overrideredirect $tip 1
+	label $tip.l -background #ffffe0 -foreground black -justify left
+	pack $tip.l
+}
+$tip.l configure -text %[2]s
+wm geometry $tip +[expr {%[3]s + 12}]+[expr {%[4]s + 12}]
+wm deiconify $tip
+raise $tip
+`, w, ctx.TclSafeString(strings.Join(tips, "\n")), a[1], a[2]))
+}
+
+func (w *EditorWidget) hideTooltip() {
+	ctx.EvalErr(fmt.Sprintf("if {[winfo exists %[1]s.tip]} {wm withdraw %[1]s.tip}", w))
+}
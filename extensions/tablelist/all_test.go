@@ -0,0 +1,91 @@
+// Copyright 2025 The tk9.0-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tablelist // import "modernc.org/tk9.0/extensions/tablelist"
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	. "modernc.org/tk9.0"
+)
+
+var (
+	tkOnce sync.Once
+	tkErr  error
+)
+
+// needTk skips the test if the extension cannot be initialized, for example
+// when there is no display.
+func needTk(t *testing.T) {
+	t.Helper()
+	tkOnce.Do(func() {
+		tkErr = Extensions[ExtensionKey{Type: "modernc.org/tk9.0/extensions/tablelist.extension", Name: "tablelist"}].Initialize(nil)
+	})
+	if tkErr != nil {
+		t.Skip(tkErr)
+	}
+}
+
+func TestList(t *testing.T) {
+	needTk(t)
+	for i, v := range []struct {
+		values []any
+		e      string
+	}{
+		{nil, "[list ]"},
+		{[]any{"foo.go", 1234}, "[list foo.go 1234]"},
+		{[]any{"", "a b", "[x]", 1.5}, `[list {} a\x20b \x5bx\x5d 1.5]`},
+	} {
+		if g, e := list(v.values), v.e; g != e {
+			t.Errorf("%v: got %s, expected %s", i, g, e)
+		}
+	}
+}
+
+func TestColumnsList(t *testing.T) {
+	needTk(t)
+	g := columnsList([]ColumnSpec{
+		{Title: "Name"},
+		{Title: "File size", Width: 8, Align: "right"},
+	})
+	if e := `[list 0 Name left 8 File\x20size right]`; g != e {
+		t.Errorf("got %s, expected %s", g, e)
+	}
+}
+
+func TestColumnOptions(t *testing.T) {
+	for i, v := range []struct {
+		c ColumnSpec
+		e string
+	}{
+		{ColumnSpec{Title: "x"}, "[]"},
+		{ColumnSpec{Name: "size", SortMode: "integer"}, "[-name size -sortmode integer]"},
+		{ColumnSpec{Editable: true}, "[-editable 1]"},
+	} {
+		if g, e := fmt.Sprint(columnOptions(v.c)), v.e; g != e {
+			t.Errorf("%v: got %s, expected %s", i, g, e)
+		}
+	}
+
+	r := columnOptions(ColumnSpec{SortMode: "real", Formatter: func(s string) string { return s + "%" }})
+	if len(r) != 3 || r[0] != "-sortmode" || r[1] != "real" {
+		t.Fatalf("got %v", r)
+	}
+
+	if _, ok := r[2].(Opt); !ok {
+		t.Errorf("formatter: got %T, expected Opt", r[2])
+	}
+}
+
+func TestTablelistParent(t *testing.T) {
+	needTk(t)
+	w := Tablelist([]ColumnSpec{{Title: "Name"}}, App)
+	defer Destroy(w)
+	if g := w.String(); !strings.HasPrefix(g, ".tablelist") {
+		t.Errorf("got %s, expected .tablelist<n>", g)
+	}
+}
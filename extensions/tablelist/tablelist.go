@@ -11,6 +11,11 @@
 // See the [modernc.org/tk9.0.Extension] documentation for information about
 // initalizing extensions at runtime.
 //
+// [Tablelist] creates a tablelist from a list of [ColumnSpec]s and the
+// [TablelistWidget] methods manage rows, cells, sorting and selection without
+// writing Tcl. [Tablelist0] and [TablelistWidget.Do] give access to the full
+// Tcl API.
+//
 // [modernc.org/tk9.0.Extension]: https://pkg.go.dev/modernc.org/tk9.0#Extension
 // [tklib tablelist]: https://github.com/tcltk/tklib/tree/master/modules/tablelist
 package tablelist // import "modernc.org/tk9.0/extensions/tablelist"
@@ -18,6 +23,7 @@ package tablelist // import "modernc.org/tk9.0/extensions/tablelist"
 import (
 	_ "embed"
 	"fmt"
+	"strconv"
 	"strings"
 
 	. "modernc.org/tk9.0"
@@ -30,6 +36,7 @@ var (
 	zip string
 
 	ctx         ExtensionContext
+	id0         int
 	initialized bool
 
 	// Version reports the version of the tablelist package. Valid after successful
@@ -85,6 +92,9 @@ func (e *extension) Initialize(context ExtensionContext) error {
 // [here]: https://www.nemethi.de/tablelist/index.html
 type TablelistWidget struct {
 	*Window
+
+	columns   []ColumnSpec
+	onEditEnd []func(EditEvent)
 }
 
 // Tablelist0 returns a newly created tablelist using raw Tcl string 'args'.
@@ -111,3 +121,243 @@ func Tablelist0(args string) (r *TablelistWidget) {
 func (t *TablelistWidget) Do(args string) string {
 	return ctx.EvalErr(fmt.Sprintf("%s %s", t, args))
 }
+
+// ColumnSpec describes a tablelist column.
+type ColumnSpec struct {
+	// Title is the text of the column header.
+	Title string
+	// Name, if not empty, can be used instead of the column number in Tcl
+	// column indices.
+	Name string
+	// Width is the width of the column in average character widths. Zero
+	// makes the column as wide as its widest element.
+	Width int
+	// Align is one of "left", "right" or "center". Defaults to "left".
+	Align string
+	// SortMode is one of "ascii", "asciinocase", "dictionary", "integer",
+	// "real" or "command". Defaults to "ascii".
+	SortMode string
+	// Editable enables interactive editing of the column cells.
+	Editable bool
+	// Formatter, if not nil, returns the text displayed for the cell
+	// content 'value'. The cell content itself is not changed.
+	Formatter func(value string) string
+	// Validate, if not nil, is called when the interactive editing of a cell
+	// in the column ends. It returns the new cell content or an error
+	// rejecting the edit.
+	Validate func(row int, text string) (string, error)
+}
+
+// EditEvent describes the end of an interactive cell edit.
+type EditEvent struct {
+	Row, Col int
+	// Text is the new cell content.
+	Text string
+	// Err is the error returned by ColumnSpec.Validate. The edit was
+	// rejected if Err is not nil.
+	Err error
+}
+
+// Tablelist returns a newly created TablelistWidget with 'columns'. Pass the
+// parent *Window as the first option to make the widget parented. Other
+// options are passed to the tablelist::tablelist command. Example:
+//
+//	t := Tablelist([]ColumnSpec{
+//		{Title: "Name", Editable: true},
+//		{Title: "Size", Align: "right", SortMode: "integer"},
+//	}, Height(10))
+//	t.Insert("end", []any{"foo.go", 1234}, []any{"bar.go", 42})
+func Tablelist(columns []ColumnSpec, options ...any) (r *TablelistWidget) {
+	parent := ""
+	if len(options) != 0 {
+		if x, ok := options[0].(*Window); ok {
+			if parent = x.String(); parent == "." {
+				parent = ""
+			}
+			options = options[1:]
+		}
+	}
+	id0++
+	path := fmt.Sprintf("%s.tablelist%v", parent, id0)
+	r = &TablelistWidget{Window: ctx.RegisterWindow(path), columns: columns}
+	options = append(options, NewEventHandler("-editendcommand", r.editEnd))
+	ctx.EvalErr(fmt.Sprintf("tablelist::tablelist %s -columns %s %s", path, columnsList(columns), ctx.Collect(r.Window, options...)))
+	for i, v := range columns {
+		if b := columnOptions(v); len(b) != 0 {
+			ctx.EvalErr(fmt.Sprintf("%s columnconfigure %d %s", r, i, ctx.Collect(r.Window, b...)))
+		}
+	}
+	return r
+}
+
+// columnsList returns the value of the -columns option for 'columns'.
+func columnsList(columns []ColumnSpec) string {
+	var a []any
+	for _, v := range columns {
+		align := v.Align
+		if align == "" {
+			align = "left"
+		}
+		a = append(a, v.Width, v.Title, align)
+	}
+	return list(a)
+}
+
+// columnOptions returns the columnconfigure options of 'c', if any.
+func columnOptions(c ColumnSpec) (r []any) {
+	if c.Name != "" {
+		r = append(r, "-name", c.Name)
+	}
+	if c.SortMode != "" {
+		r = append(r, "-sortmode", c.SortMode)
+	}
+	if c.Editable {
+		r = append(r, "-editable", "1")
+	}
+	if f := c.Formatter; f != nil {
+		r = append(r, NewEventHandler("-formatcommand", func(e *Event) {
+			if args := e.Args(); len(args) != 0 {
+				e.Result = f(args[len(args)-1])
+			}
+		}))
+	}
+	return r
+}
+
+// editEnd handles the -editendcommand invoked as 'cmd win row col text'.
+func (t *TablelistWidget) editEnd(e *Event) {
+	args := e.Args()
+	if len(args) < 4 {
+		return
+	}
+
+	ev := EditEvent{Row: atoi(args[1]), Col: atoi(args[2]), Text: args[3]}
+	if ev.Col >= 0 && ev.Col < len(t.columns) {
+		if f := t.columns[ev.Col].Validate; f != nil {
+			ev.Text, ev.Err = f(ev.Row, ev.Text)
+		}
+	}
+	if ev.Err != nil {
+		ctx.EvalErr(fmt.Sprintf("%s rejectinput", t))
+		ev.Text = args[3]
+	}
+	e.Result = ev.Text
+	for _, f := range t.onEditEnd {
+		f(ev)
+	}
+}
+
+func atoi(s string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return -1
+	}
+
+	return n
+}
+
+func list(values []any) string {
+	a := make([]string, len(values))
+	for i, v := range values {
+		a[i] = ctx.TclSafeString(fmt.Sprint(v))
+	}
+	return fmt.Sprintf("[list %s]", strings.Join(a, " "))
+}
+
+// Columns returns the column definitions of 't'.
+func (t *TablelistWidget) Columns() []ColumnSpec {
+	return t.columns
+}
+
+// Insert inserts 'rows' before the row at 'index', an integer or "end".
+func (t *TablelistWidget) Insert(index any, rows ...[]any) {
+	a := make([]string, len(rows))
+	for i, v := range rows {
+		a[i] = list(v)
+	}
+	ctx.EvalErr(fmt.Sprintf("%s insert %s %s", t, ctx.TclSafeString(fmt.Sprint(index)), strings.Join(a, " ")))
+}
+
+// Row returns the cell contents of the row at 'index'.
+func (t *TablelistWidget) Row(index int) []string {
+	return ParseList(ctx.EvalErr(fmt.Sprintf("%s rowcget %d -text", t, index)))
+}
+
+// Rows returns the cell contents of all rows.
+func (t *TablelistWidget) Rows() (r [][]string) {
+	for _, v := range ParseList(ctx.EvalErr(fmt.Sprintf("%s get 0 end", t))) {
+		r = append(r, ParseList(v))
+	}
+	return r
+}
+
+// Size returns the number of rows.
+func (t *TablelistWidget) Size() int {
+	return atoi(ctx.EvalErr(fmt.Sprintf("%s size", t)))
+}
+
+// Delete deletes the rows from 'first' to 'last', inclusive.
+func (t *TablelistWidget) Delete(first, last int) {
+	ctx.EvalErr(fmt.Sprintf("%s delete %d %d", t, first, last))
+}
+
+// Cell returns the content of the cell at 'row' and 'col'.
+func (t *TablelistWidget) Cell(row, col int) string {
+	return ctx.EvalErr(fmt.Sprintf("%s cellcget %d,%d -text", t, row, col))
+}
+
+// SetCell sets the content of the cell at 'row' and 'col'.
+func (t *TablelistWidget) SetCell(row, col int, value any) {
+	ctx.EvalErr(fmt.Sprintf("%s cellconfigure %d,%d -text %s", t, row, col, ctx.TclSafeString(fmt.Sprint(value))))
+}
+
+// EditCell starts the interactive editing of the cell at 'row' and 'col'.
+func (t *TablelistWidget) EditCell(row, col int) {
+	ctx.EvalErr(fmt.Sprintf("%s editcell %d,%d", t, row, col))
+}
+
+// SortByColumn sorts the rows by the content of column 'col' using the
+// column SortMode.
+func (t *TablelistWidget) SortByColumn(col int, decreasing bool) {
+	order := "-increasing"
+	if decreasing {
+		order = "-decreasing"
+	}
+	ctx.EvalErr(fmt.Sprintf("%s sortbycolumn %d %s", t, col, order))
+}
+
+// SortOnHeaderClick makes clicking a column header sort the rows by that
+// column, toggling the sort order on repeated clicks.
+func (t *TablelistWidget) SortOnHeaderClick() {
+	ctx.EvalErr(fmt.Sprintf("%s configure -labelcommand tablelist::sortByColumn", t))
+}
+
+// Selection returns the indices of the selected rows.
+func (t *TablelistWidget) Selection() (r []int) {
+	for _, v := range ParseList(ctx.EvalErr(fmt.Sprintf("%s curselection", t))) {
+		r = append(r, atoi(v))
+	}
+	return r
+}
+
+// Select selects the rows from 'first' to 'last', inclusive.
+func (t *TablelistWidget) Select(first, last int) {
+	ctx.EvalErr(fmt.Sprintf("%s selection set %d %d", t, first, last))
+}
+
+// ClearSelection deselects all rows.
+func (t *TablelistWidget) ClearSelection() {
+	ctx.EvalErr(fmt.Sprintf("%s selection clear 0 end", t))
+}
+
+// OnSelect registers 'handler' to be called with the selected rows when the
+// selection changes interactively.
+func (t *TablelistWidget) OnSelect(handler func(rows []int)) {
+	Bind(t.Window, "<<TablelistSelect>>", Command(func() { handler(t.Selection()) }))
+}
+
+// OnEditEnd registers 'handler' to be called when the interactive editing of
+// a cell ends. It has no effect on widgets created by [Tablelist0].
+func (t *TablelistWidget) OnEditEnd(handler func(EditEvent)) {
+	t.onEditEnd = append(t.onEditEnd, handler)
+}
@@ -0,0 +1,432 @@
+// Copyright 2025 The tk9.0-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tk9_0 // import "modernc.org/tk9.0"
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// HighlightTag is the text tag used by [TextWidget.HighlightAll].
+const HighlightTag = "found"
+
+// Range is a range of text from Start up to, but not including, End. Both
+// are indices in the "line.char" form.
+type Range struct {
+	Start, End string
+}
+
+// FindOptions control [TextWidget.FindAll] and related methods.
+type FindOptions struct {
+	// Regexp interprets the pattern as a regular expression. The Go
+	// regexp syntax is used unless Tcl is set.
+	Regexp bool
+	// Nocase ignores case differences.
+	Nocase bool
+	// WholeWord matches only at word boundaries. Not supported with Tcl,
+	// the search returns an error.
+	WholeWord bool
+	// Tcl uses the Tk text search command and, with Regexp, the Tcl regular
+	// expression syntax. Replacement templates are expanded by the Tcl regsub
+	// command applied to the matched text alone. Constructs depending on the
+	// text around the match, like lookahead constraints or the \m and \M word
+	// boundaries, may fail to match the whole text again, the replacement
+	// then returns an error and changes nothing.
+	Tcl bool
+}
+
+// match is a match of a pattern.
+type match struct {
+	Range
+	expand func(template string) (string, error)
+}
+
+// FindAll returns the ranges of all non-overlapping matches of 'pattern'.
+//
+// Unless opts.Tcl is set, the matching is done in Go on the content returned
+// by [TextWidget.Get], so embedded images and windows, which occupy an index
+// but do not appear in the content, make the returned indices inaccurate.
+func (w *TextWidget) FindAll(pattern string, opts FindOptions) (r []Range, err error) {
+	m, err := w.findAll(pattern, opts)
+	for _, v := range m {
+		r = append(r, v.Range)
+	}
+	return r, err
+}
+
+func (w *TextWidget) findAll(pattern string, opts FindOptions) (r []match, err error) {
+	if pattern == "" {
+		return nil, nil
+	}
+
+	if opts.Tcl {
+		if opts.WholeWord {
+			return nil, fmt.Errorf("WholeWord is not supported with Tcl")
+		}
+
+		return w.findAllTcl(pattern, opts)
+	}
+
+	if !opts.Regexp {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if opts.WholeWord {
+		pattern = `\b(?:` + pattern + `)\b`
+	}
+	if opts.Nocase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	return findText(evalErr(fmt.Sprintf("%s get 1.0 {end - 1c}", w)), re, opts.Regexp), nil
+}
+
+// findText returns the matches of 're' in 'text'. If 'expand' is false,
+// templates are inserted literally.
+func findText(text string, re *regexp.Regexp, expand bool) (r []match) {
+	lines := []int{0} // byte offsets of line starts
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+	index := func(off int) string {
+		line := sort.Search(len(lines), func(i int) bool { return lines[i] > off }) - 1
+		return fmt.Sprintf("%d.%d", line+1, utf8.RuneCountInString(text[lines[line]:off]))
+	}
+	for _, v := range re.FindAllStringSubmatchIndex(text, -1) {
+		if v[0] == v[1] {
+			continue
+		}
+
+		v := v
+		r = append(r, match{
+			Range: Range{index(v[0]), index(v[1])},
+			expand: func(template string) (string, error) {
+				if !expand {
+					return template, nil
+				}
+
+				return string(re.ExpandString(nil, template, text, v)), nil
+			},
+		})
+	}
+	return r
+}
+
+func (w *TextWidget) findAllTcl(pattern string, opts FindOptions) (r []match, err error) {
+	var flags []string
+	if opts.Regexp {
+		flags = append(flags, "-regexp")
+	}
+	if opts.Nocase {
+		flags = append(flags, "-nocase")
+	}
+	flag := strings.Join(flags, " ")
+	s, err := eval(fmt.Sprintf("set ::tk9_0_count {}; %s search -all -count ::tk9_0_count %s -- %s 1.0 end", w, flag, tclSafeString(pattern)))
+	if err != nil {
+		return nil, err
+	}
+
+	starts := parseList(s)
+	counts := parseList(evalErr("set ::tk9_0_count"))
+	if len(starts) == 0 || len(starts) != len(counts) {
+		return nil, nil
+	}
+
+	var b strings.Builder
+	b.WriteString("list")
+	for i, v := range starts {
+		fmt.Fprintf(&b, " [%s index {%s + %s chars}] [%[1]s get {%[2]s} {%[2]s + %[3]s chars}]", w, v, counts[i])
+	}
+	a := parseList(evalErr(b.String()))
+	for i, v := range starts {
+		text := a[2*i+1]
+		r = append(r, match{
+			Range: Range{v, a[2*i]},
+			expand: func(template string) (string, error) {
+				if !opts.Regexp {
+					return template, nil
+				}
+
+				return tclExpand(pattern, text, template, opts.Nocase)
+			},
+		})
+	}
+	return r, nil
+}
+
+// tclExpand returns 'template' expanded by the Tcl regsub command for 'text',
+// a match of the Tcl regular expression 'pattern'. It fails if 'pattern' does
+// not match all of 'text' on its own.
+func tclExpand(pattern, text, template string, nocase bool) (string, error) {
+	flag := ""
+	if nocase {
+		flag = "-nocase "
+	}
+	s, err := eval(fmt.Sprintf("regexp %s-inline -indices -- %s %s", flag, tclSafeString(pattern), tclSafeString(text)))
+	if err != nil {
+		return "", err
+	}
+
+	if a := parseList(s); len(a) == 0 || a[0] != fmt.Sprintf("0 %d", utf8.RuneCountInString(text)-1) {
+		return "", fmt.Errorf("the pattern does not match %q without its context", text)
+	}
+
+	return eval(fmt.Sprintf("regsub %s-- %s %s %s", flag, tclSafeString(pattern), tclSafeString(text), tclSafeString(template)))
+}
+
+// FindNext returns the first match of 'pattern' starting at or after
+// 'index', or ending at or before 'index' if 'backwards' is true. The search
+// wraps around the end, or the start, of the text.
+func (w *TextWidget) FindNext(pattern string, index any, backwards bool, opts FindOptions) (r Range, ok bool, err error) {
+	a, err := w.FindAll(pattern, opts)
+	if err != nil || len(a) == 0 {
+		return r, false, err
+	}
+
+	from := w.Index(index)
+	if backwards {
+		for i := len(a) - 1; i >= 0; i-- {
+			if !indexLess(from, a[i].End) {
+				return a[i], true, nil
+			}
+		}
+		return a[len(a)-1], true, nil
+	}
+
+	for _, v := range a {
+		if !indexLess(v.Start, from) {
+			return v, true, nil
+		}
+	}
+	return a[0], true, nil
+}
+
+// ReplaceAll replaces all matches of 'pattern' by 'template' and returns the
+// number of replacements. With opts.Regexp, the template can refer to capture
+// groups, using the syntax of [regexp.Regexp.Expand], or of the Tcl regsub
+// command if opts.Tcl is set. Otherwise the template is inserted literally.
+//
+// The replacements are undone as a single step, by the Tk undo mechanism or by
+// the [UndoManager] of the widget, if any.
+func (w *TextWidget) ReplaceAll(pattern, template string, opts FindOptions) (n int, err error) {
+	m, err := w.findAll(pattern, opts)
+	if err != nil || len(m) == 0 {
+		return 0, err
+	}
+
+	a := make([]string, len(m))
+	for i, v := range m {
+		if a[i], err = v.expand(template); err != nil {
+			return 0, err
+		}
+	}
+
+	defer w.undoGroup()()
+	for i := len(m) - 1; i >= 0; i-- {
+		w.replace(m[i], a[i])
+	}
+	return len(m), nil
+}
+
+// replace replaces the text of 'm' by 's'.
+func (w *TextWidget) replace(m match, s string) {
+	evalErr(fmt.Sprintf("%[1]s delete %[2]s %[3]s; %[1]s insert %[2]s %[4]s", w, m.Start, m.End, tclSafeString(s)))
+}
+
+// undoGroup starts grouping the following edits into one undo step. Call the
+// returned function to end the group.
+func (w *TextWidget) undoGroup() (end func()) {
+	if m := undoManagers[w.String()]; m != nil {
+		m.BeginGroup()
+		return m.EndGroup
+	}
+
+	auto := evalErr(fmt.Sprintf("%s cget -autoseparators", w))
+	evalErr(fmt.Sprintf("%[1]s edit separator; %[1]s configure -autoseparators 0", w))
+	return func() {
+		evalErr(fmt.Sprintf("%[1]s configure -autoseparators %[2]s; %[1]s edit separator", w, auto))
+	}
+}
+
+// HighlightAll tags all matches of 'pattern' with [HighlightTag], removing the
+// tag from any previous matches, and returns them. The tag has a yellow
+// background unless configured otherwise.
+func (w *TextWidget) HighlightAll(pattern string, opts FindOptions) (r []Range, err error) {
+	w.ClearHighlight()
+	if r, err = w.FindAll(pattern, opts); err != nil || len(r) == 0 {
+		return r, err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s tag add %s", w, HighlightTag)
+	for _, v := range r {
+		fmt.Fprintf(&b, " %s %s", v.Start, v.End)
+	}
+	fmt.Fprintf(&b, "\nif {[%[1]s tag cget %[2]s -background] eq {}} {%[1]s tag configure %[2]s -background yellow}", w, HighlightTag)
+	fmt.Fprintf(&b, "\n%s tag lower %s sel", w, HighlightTag)
+	evalErr(b.String())
+	return r, nil
+}
+
+// ClearHighlight removes [HighlightTag] from the text.
+func (w *TextWidget) ClearHighlight() {
+	evalErr(fmt.Sprintf("%s tag remove %s 1.0 end", w, HighlightTag))
+}
+
+// FindBarWidget is a find and replace bar operating on a TextWidget.
+//
+// Return in the find entry selects the next match, Shift-Return the previous
+// one and Escape clears the highlighting and returns the focus to the text.
+// All matches are highlighted while typing.
+type FindBarWidget struct {
+	*TFrameWidget
+	text *TextWidget
+
+	find, replace *TEntryWidget
+	nocase        *VariableOpt
+	regexp        *VariableOpt
+	status        *TLabelWidget
+	word          *VariableOpt
+}
+
+// FindBar returns a new find and replace bar for 'text'.
+func FindBar(text *TextWidget, options ...Opt) *FindBarWidget {
+	return App.FindBar(text, options...)
+}
+
+// FindBar returns a new find and replace bar for 'text'.
+//
+// The resulting [Window] is a child of 'w'
+func (w *Window) FindBar(text *TextWidget, options ...Opt) (r *FindBarWidget) {
+	r = &FindBarWidget{
+		TFrameWidget: w.TFrame(options...),
+		text:         text,
+		nocase:       Variable(false),
+		regexp:       Variable(false),
+		word:         Variable(false),
+	}
+	f := r.Window
+	r.find = f.TEntry(Width(30))
+	r.replace = f.TEntry(Width(30))
+	r.status = f.TLabel(Width(16))
+	opts := Opts{Padx("0.5m"), Pady("0.5m"), Sticky(W)}
+	Grid(f.TLabel(Txt("Find:")), r.find,
+		f.TButton(Txt("Previous"), Command(func() { r.Next(true) })),
+		f.TButton(Txt("Next"), Command(func() { r.Next(false) })),
+		f.TCheckbutton(Txt("Regexp"), r.regexp, Command(r.highlight)),
+		f.TCheckbutton(Txt("Match case"), Command(r.highlight), Onvalue(0), Offvalue(1), r.nocase),
+		f.TCheckbutton(Txt("Whole word"), r.word, Command(r.highlight)),
+		opts)
+	Grid(f.TLabel(Txt("Replace:")), r.replace,
+		f.TButton(Txt("Replace"), Command(r.Replace)),
+		f.TButton(Txt("Replace all"), Command(r.ReplaceAll)),
+		r.status, opts)
+	GridColumnConfigure(f, 1, Weight(1))
+	r.nocase.Set(1)
+	Bind(r.find.Window, "<Return>", Command(func() { r.Next(false) }))
+	Bind(r.find.Window, "<Shift-Return>", Command(func() { r.Next(true) }))
+	Bind(r.find.Window, "<KeyRelease>", Command(r.highlight))
+	for _, v := range []*Window{r.find.Window, r.replace.Window} {
+		Bind(v, "<Escape>", Command(func() {
+			text.ClearHighlight()
+			Focus(text)
+		}))
+	}
+	return r
+}
+
+// Focus moves the focus to the find entry and selects its content.
+func (r *FindBarWidget) Focus() {
+	evalErr(fmt.Sprintf("focus %[1]s; %[1]s selection range 0 end", r.find))
+}
+
+// Options returns the options selected in the bar.
+func (r *FindBarWidget) Options() FindOptions {
+	return FindOptions{
+		Regexp:    r.regexp.Get() == "1",
+		Nocase:    r.nocase.Get() == "1",
+		WholeWord: r.word.Get() == "1",
+	}
+}
+
+func (r *FindBarWidget) pattern() string {
+	return evalErr(fmt.Sprintf("%s get", r.find))
+}
+
+func (r *FindBarWidget) highlight() {
+	a, err := r.text.HighlightAll(r.pattern(), r.Options())
+	switch {
+	case err != nil:
+		r.status.Configure(Txt(err.Error()))
+	case r.pattern() == "":
+		r.status.Configure(Txt(""))
+	default:
+		r.status.Configure(Txt(fmt.Sprintf("%d matches", len(a))))
+	}
+}
+
+// Next selects the next match after the insertion cursor, or the previous
+// one if 'backwards' is true, and scrolls it into view.
+func (r *FindBarWidget) Next(backwards bool) {
+	r.highlight()
+	from := "insert"
+	if backwards {
+		if a := r.text.TagRanges("sel"); len(a) != 0 {
+			from = a[0]
+		}
+	}
+	m, ok, err := r.text.FindNext(r.pattern(), from, backwards, r.Options())
+	if err != nil || !ok {
+		return
+	}
+
+	evalErr(fmt.Sprintf("%[1]s tag remove sel 1.0 end; %[1]s tag add sel %[2]s %[3]s; %[1]s mark set insert %[3]s; %[1]s see %[2]s", r.text, m.Start, m.End))
+}
+
+// Replace replaces the selected match, if any, and selects the next one.
+func (r *FindBarWidget) Replace() {
+	if a := r.text.TagRanges("sel"); len(a) == 2 {
+		m, err := r.text.findAll(r.pattern(), r.Options())
+		if err != nil {
+			return
+		}
+
+		for _, v := range m {
+			if v.Start == a[0] && v.End == a[1] {
+				s, err := v.expand(evalErr(fmt.Sprintf("%s get", r.replace)))
+				if err != nil {
+					r.status.Configure(Txt(err.Error()))
+					return
+				}
+
+				end := r.text.undoGroup()
+				r.text.replace(v, s)
+				end()
+				break
+			}
+		}
+	}
+	r.Next(false)
+}
+
+// ReplaceAll replaces all matches.
+func (r *FindBarWidget) ReplaceAll() {
+	n, err := r.text.ReplaceAll(r.pattern(), evalErr(fmt.Sprintf("%s get", r.replace)), r.Options())
+	r.text.ClearHighlight()
+	switch {
+	case err != nil:
+		r.status.Configure(Txt(err.Error()))
+	default:
+		r.status.Configure(Txt(fmt.Sprintf("%d replaced", n)))
+	}
+}
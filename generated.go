@@ -8392,28 +8392,6 @@ func (w *Window) Use() string {
 	return evalErr(fmt.Sprintf(`%s cget -use`, w))
 }
 
-// Validate option.
-//
-// Known uses:
-//   - [Entry] (widget specific)
-//   - [Spinbox] (widget specific)
-//   - [TEntry] (widget specific)
-//   - [TSpinbox]
-func Validate(val any) Opt {
-	return rawOption(fmt.Sprintf(`-validate %s`, optionString(val)))
-}
-
-// Validate — Get the configured option value.
-//
-// Known uses:
-//   - [Entry] (widget specific)
-//   - [Spinbox] (widget specific)
-//   - [TEntry] (widget specific)
-//   - [TSpinbox]
-func (w *Window) Validate() string {
-	return evalErr(fmt.Sprintf(`%s cget -validate`, w))
-}
-
 // Validatecommand option.
 //
 // See also [Event handlers].
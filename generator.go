@@ -501,12 +501,13 @@ var (
 	}
 
 	hideOpts = map[string]bool{
-		"Data":   true,
-		"Font":   true,
-		"From":   true,
-		"To":     true,
-		"Type":   true,
-		"Values": true,
+		"Data":     true,
+		"Font":     true,
+		"From":     true,
+		"To":       true,
+		"Type":     true,
+		"Validate": true,
+		"Values":   true,
 	}
 
 	hideOptMethods = map[string]bool{
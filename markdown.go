@@ -0,0 +1,1191 @@
+// Copyright 2025 The tk9.0-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tk9_0 // import "modernc.org/tk9.0"
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Markdown block kinds.
+const (
+	mdParagraph = iota
+	mdHeading
+	mdCode
+	mdQuote
+	mdList
+	mdItem
+	mdRule
+	mdTable
+)
+
+// Markdown inline kinds.
+const (
+	mdText = iota
+	mdSoftBreak
+	mdHardBreak
+	mdCodeSpan
+	mdEmph
+	mdStrong
+	mdDel
+	mdLink
+	mdImage
+)
+
+var (
+	mdATXRe       = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	mdFenceRe     = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})[ \t]*(.*)$")
+	mdRuleRe      = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	mdSetextRe    = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	mdQuoteRe     = regexp.MustCompile(`^ {0,3}> ?`)
+	mdItemRe      = regexp.MustCompile(`^( {0,3})([-+*]|[0-9]{1,9}[.)])( +|$)`)
+	mdDelimRowRe  = regexp.MustCompile(`^ {0,3}\|?[ \t]*:?-+:?[ \t]*(\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+	mdRefDefRe    = regexp.MustCompile(`^ {0,3}\[((?:[^\]\\]|\\.)+)\]:[ \t]*(<[^>\n]*>|\S+)(?:[ \t]+("(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'|\((?:[^)\\]|\\.)*\)))?[ \t]*$`)
+	mdAutolinkRe  = regexp.MustCompile(`^<([a-zA-Z][a-zA-Z0-9+.-]{1,31}:[^<>\x00-\x20]*)>`)
+	mdEmailRe     = regexp.MustCompile(`^<([a-zA-Z0-9.!#$%&'*+/=?^_{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*)>`)
+	mdBareURLRe   = regexp.MustCompile(`^(?:https?://|www\.)[^\s<]*[^\s<?!.,:*_~'")\]]`)
+	mdEntityRe    = regexp.MustCompile(`^&(?:#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|[a-zA-Z][a-zA-Z0-9]{1,31});`)
+	mdBullets     = []string{"•", "◦", "▪"}
+	mdPunctuation = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+
+	// Images loaded from files by InsertMarkdown, per TextWidget and path.
+	mdImages = map[*Window]map[string]string{}
+)
+
+// mdBlock is a Markdown block node.
+type mdBlock struct {
+	kind     int
+	level    int        // Heading level.
+	lines    []string   // Paragraph and heading source lines, code block lines.
+	info     string     // Fenced code block info string.
+	children []*mdBlock // Blockquote, list and list item content.
+	ordered  bool       // Ordered list.
+	start    int        // Ordered list start number.
+	tight    bool       // List is tight.
+	marker   string     // List item marker, used to group items.
+	align    []string   // Table column alignment.
+	rows     [][]string // Table cells, the first row is the header.
+}
+
+// mdInline is a Markdown inline node.
+type mdInline struct {
+	kind     int
+	text     string
+	href     string
+	title    string
+	children []*mdInline
+
+	// Delimiter run and bracket state used while parsing.
+	delim    byte
+	count    int
+	canOpen  bool
+	canClose bool
+	bracket  bool // '[' or '![' link/image opener.
+	active   bool // Bracket can still start a link.
+	pos      int  // Source position after the bracket.
+}
+
+type mdLinkRef struct {
+	href  string
+	title string
+}
+
+// mdDoc is a parsed Markdown document.
+type mdDoc struct {
+	blocks []*mdBlock
+	refs   map[string]mdLinkRef
+}
+
+func parseMarkdown(src string) (r *mdDoc) {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\r", "\n")
+	src = strings.ReplaceAll(src, "\x00", "�")
+	lines := strings.Split(strings.TrimSuffix(src, "\n"), "\n")
+	for i, v := range lines {
+		lines[i] = mdExpandTabs(v)
+	}
+	r = &mdDoc{refs: map[string]mdLinkRef{}}
+	r.blocks = r.parseBlocks(lines)
+	return r
+}
+
+// mdExpandTabs replaces tabs by spaces using tab stops of 4 columns.
+func mdExpandTabs(s string) string {
+	if !strings.Contains(s, "\t") {
+		return s
+	}
+
+	var b strings.Builder
+	col := 0
+	for _, c := range s {
+		switch c {
+		case '\t':
+			n := 4 - col%4
+			b.WriteString(strings.Repeat(" ", n))
+			col += n
+		default:
+			b.WriteRune(c)
+			col++
+		}
+	}
+	return b.String()
+}
+
+func mdIsBlank(s string) bool {
+	return strings.TrimSpace(s) == ""
+}
+
+func mdIndent(s string) (r int) {
+	for r < len(s) && s[r] == ' ' {
+		r++
+	}
+	return r
+}
+
+// mdItemStart reports whether 'line' starts a list item and returns the
+// marker and the content indent.
+func mdItemStart(line string) (marker string, indent int, ok bool) {
+	m := mdItemRe.FindStringSubmatch(line)
+	if m == nil {
+		return "", 0, false
+	}
+
+	marker = m[2]
+	indent = len(m[1]) + len(marker)
+	switch spaces := len(m[3]); {
+	case spaces == 0:
+		indent++
+	case spaces > 4:
+		indent++
+	default:
+		indent += spaces
+	}
+	return marker, indent, true
+}
+
+// mdInterrupts reports whether 'line' starts a block that can interrupt a
+// paragraph.
+func mdInterrupts(line string) bool {
+	if mdATXRe.MatchString(line) || mdFenceRe.MatchString(line) || mdRuleRe.MatchString(line) || mdQuoteRe.MatchString(line) {
+		return true
+	}
+
+	if marker, indent, ok := mdItemStart(line); ok {
+		if mdIsBlank(line[min(indent, len(line)):]) {
+			return false // An empty list item cannot interrupt a paragraph.
+		}
+
+		if c := marker[len(marker)-1]; (c == '.' || c == ')') && marker[:len(marker)-1] != "1" {
+			return false
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// mdTableCells splits a table row into cells.
+func mdTableCells(line string) (r []string) {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+	var b strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '\\' && i+1 < len(line) && line[i+1] == '|':
+			b.WriteByte('|')
+			i++
+		case c == '|':
+			r = append(r, strings.TrimSpace(b.String()))
+			b.Reset()
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return append(r, strings.TrimSpace(b.String()))
+}
+
+func (d *mdDoc) parseBlocks(lines []string) (r []*mdBlock) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case mdIsBlank(line):
+			i++
+		case mdIndent(line) >= 4:
+			b := &mdBlock{kind: mdCode}
+			for ; i < len(lines) && (mdIndent(lines[i]) >= 4 || mdIsBlank(lines[i])); i++ {
+				s := lines[i]
+				b.lines = append(b.lines, s[min(4, len(s)):])
+			}
+			for len(b.lines) != 0 && mdIsBlank(b.lines[len(b.lines)-1]) {
+				b.lines = b.lines[:len(b.lines)-1]
+			}
+			r = append(r, b)
+		case mdFenceRe.MatchString(line):
+			m := mdFenceRe.FindStringSubmatch(line)
+			indent, fence, info := len(m[1]), m[2], m[3]
+			if fence[0] == '`' && strings.Contains(info, "`") {
+				r, i = d.paragraph(r, lines, i)
+				break
+			}
+
+			b := &mdBlock{kind: mdCode, info: strings.TrimSpace(info)}
+			for i++; i < len(lines); i++ {
+				s := lines[i]
+				if t := strings.TrimSpace(s); mdIndent(s) < 4 && len(t) >= len(fence) && strings.Trim(t, fence[:1]) == "" {
+					i++
+					break
+				}
+
+				n := min(indent, mdIndent(s))
+				b.lines = append(b.lines, s[n:])
+			}
+			r = append(r, b)
+		case mdATXRe.MatchString(line):
+			m := mdATXRe.FindStringSubmatch(line)
+			r = append(r, &mdBlock{kind: mdHeading, level: len(m[1]), lines: []string{m[2]}})
+			i++
+		case mdRuleRe.MatchString(line):
+			r = append(r, &mdBlock{kind: mdRule})
+			i++
+		case mdQuoteRe.MatchString(line):
+			var inner []string
+			for ; i < len(lines); i++ {
+				s := lines[i]
+				if loc := mdQuoteRe.FindStringIndex(s); loc != nil {
+					inner = append(inner, s[loc[1]:])
+					continue
+				}
+
+				// Lazy continuation of a paragraph.
+				if mdIsBlank(s) || len(inner) == 0 || mdIsBlank(inner[len(inner)-1]) || mdInterrupts(s) || mdIndent(inner[len(inner)-1]) >= 4 {
+					break
+				}
+
+				inner = append(inner, s)
+			}
+			r = append(r, &mdBlock{kind: mdQuote, children: d.parseBlocks(inner)})
+		default:
+			if _, _, ok := mdItemStart(line); ok {
+				var list *mdBlock
+				list, i = d.list(lines, i)
+				r = append(r, list)
+				break
+			}
+
+			if i+1 < len(lines) && strings.Contains(line, "|") && mdDelimRowRe.MatchString(lines[i+1]) {
+				if hdr, delim := mdTableCells(line), mdTableCells(lines[i+1]); len(hdr) == len(delim) {
+					b := &mdBlock{kind: mdTable, rows: [][]string{hdr}}
+					for _, v := range delim {
+						switch {
+						case strings.HasPrefix(v, ":") && strings.HasSuffix(v, ":"):
+							b.align = append(b.align, "center")
+						case strings.HasSuffix(v, ":"):
+							b.align = append(b.align, "right")
+						case strings.HasPrefix(v, ":"):
+							b.align = append(b.align, "left")
+						default:
+							b.align = append(b.align, "")
+						}
+					}
+					for i += 2; i < len(lines) && !mdIsBlank(lines[i]) && !mdInterrupts(lines[i]); i++ {
+						row := mdTableCells(lines[i])
+						for len(row) < len(hdr) {
+							row = append(row, "")
+						}
+						b.rows = append(b.rows, row[:len(hdr)])
+					}
+					r = append(r, b)
+					break
+				}
+			}
+
+			r, i = d.paragraph(r, lines, i)
+		}
+	}
+	return r
+}
+
+// paragraph parses a paragraph starting at lines[i], which can turn into a
+// setext heading or link reference definitions.
+func (d *mdDoc) paragraph(r []*mdBlock, lines []string, i int) ([]*mdBlock, int) {
+	b := &mdBlock{kind: mdParagraph}
+	for ; i < len(lines); i++ {
+		s := lines[i]
+		if mdIsBlank(s) {
+			break
+		}
+
+		if len(b.lines) != 0 {
+			if m := mdSetextRe.FindStringSubmatch(s); m != nil {
+				b.kind = mdHeading
+				b.level = 1
+				if m[1][0] == '-' {
+					b.level = 2
+				}
+				i++
+				break
+			}
+
+			if mdInterrupts(s) {
+				break
+			}
+		}
+
+		b.lines = append(b.lines, strings.TrimLeft(s, " "))
+	}
+	// Link reference definitions at the paragraph start.
+	for b.kind == mdParagraph && len(b.lines) != 0 {
+		m := mdRefDefRe.FindStringSubmatch(b.lines[0])
+		if m == nil {
+			break
+		}
+
+		href := m[2]
+		if strings.HasPrefix(href, "<") {
+			if len(href) < 2 || !strings.HasSuffix(href, ">") {
+				// An unclosed destination, the line is not a definition.
+				break
+			}
+
+			href = href[1 : len(href)-1]
+		}
+		label := mdNormalizeLabel(m[1])
+		if _, ok := d.refs[label]; !ok && label != "" {
+			title := m[3]
+			if title != "" {
+				title = title[1 : len(title)-1]
+			}
+			d.refs[label] = mdLinkRef{mdUnescape(href), mdUnescape(title)}
+		}
+		b.lines = b.lines[1:]
+	}
+	if len(b.lines) != 0 {
+		r = append(r, b)
+	}
+	return r, i
+}
+
+// list parses a list starting at lines[i].
+func (d *mdDoc) list(lines []string, i int) (r *mdBlock, _ int) {
+	marker0, _, _ := mdItemStart(lines[i])
+	r = &mdBlock{kind: mdList, tight: true}
+	if c := marker0[len(marker0)-1]; c == '.' || c == ')' {
+		r.ordered = true
+		r.start, _ = strconv.Atoi(marker0[:len(marker0)-1])
+	}
+	blankBetween := false
+	for i < len(lines) {
+		marker, indent, ok := mdItemStart(lines[i])
+		if !ok || mdItemKey(marker) != mdItemKey(marker0) {
+			break
+		}
+
+		if blankBetween {
+			r.tight = false
+		}
+		first := lines[i]
+		inner := []string{first[min(indent, len(first)):]}
+		lastBlank := false
+		for i++; i < len(lines); i++ {
+			s := lines[i]
+			if mdIsBlank(s) {
+				inner = append(inner, "")
+				lastBlank = true
+				continue
+			}
+
+			if mdIndent(s) >= indent {
+				inner = append(inner, s[indent:])
+				lastBlank = false
+				continue
+			}
+
+			// Lazy continuation of a paragraph.
+			if !lastBlank && !mdInterrupts(s) && !mdIsBlank(inner[len(inner)-1]) {
+				if _, _, ok := mdItemStart(s); !ok {
+					inner = append(inner, s)
+					continue
+				}
+			}
+
+			break
+		}
+		// Trailing blank lines belong between the items.
+		n := len(inner)
+		for n > 0 && inner[n-1] == "" {
+			n--
+		}
+		blankBetween = n < len(inner)
+		inner = inner[:n]
+		for j := 1; j < len(inner); j++ {
+			if inner[j] == "" && j+1 < len(inner) && mdIndent(inner[j+1]) == 0 && !mdInCode(inner[:j]) {
+				if _, _, ok := mdItemStart(inner[j+1]); ok {
+					continue // Blank line in a nested list.
+				}
+
+				r.tight = false
+			}
+		}
+		r.children = append(r.children, &mdBlock{kind: mdItem, marker: marker, children: d.parseBlocks(inner)})
+	}
+	return r, i
+}
+
+// mdInCode reports whether the lines end inside an open fenced code block.
+func mdInCode(lines []string) (r bool) {
+	var fence string
+	for _, v := range lines {
+		switch {
+		case fence == "":
+			if m := mdFenceRe.FindStringSubmatch(v); m != nil {
+				fence = m[2]
+			}
+		default:
+			if t := strings.TrimSpace(v); len(t) >= len(fence) && strings.Trim(t, fence[:1]) == "" {
+				fence = ""
+			}
+		}
+	}
+	return fence != ""
+}
+
+// mdItemKey returns the list item marker kind; items with different kinds
+// start a new list.
+func mdItemKey(marker string) string {
+	c := marker[len(marker)-1]
+	switch c {
+	case '.', ')':
+		return string(c)
+	default:
+		return marker
+	}
+}
+
+// mdNormalizeLabel returns the link reference label used for matching.
+func mdNormalizeLabel(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// mdUnescape handles backslash escapes and entities.
+func mdUnescape(s string) string {
+	if !strings.ContainsAny(s, "\\&") {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(mdPunctuation, s[i+1]) >= 0 {
+			b.WriteByte(s[i+1])
+			i++
+			continue
+		}
+
+		b.WriteByte(s[i])
+	}
+	return html.UnescapeString(b.String())
+}
+
+// mdRuneBefore returns the rune preceding s[i] or a space.
+func mdRuneBefore(s string, i int) rune {
+	if i <= 0 {
+		return ' '
+	}
+
+	r, _ := utf8.DecodeLastRuneInString(s[:i])
+	return r
+}
+
+// mdRuneAt returns the rune at s[i] or a space.
+func mdRuneAt(s string, i int) rune {
+	if i >= len(s) {
+		return ' '
+	}
+
+	r, _ := utf8.DecodeRuneInString(s[i:])
+	return r
+}
+
+func mdIsPunct(r rune) bool {
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+// inlines parses the inline content 's'.
+func (d *mdDoc) inlines(s string) []*mdInline {
+	var nodes []*mdInline
+	var text strings.Builder
+	flush := func() {
+		if text.Len() != 0 {
+			nodes = append(nodes, &mdInline{kind: mdText, text: text.String()})
+			text.Reset()
+		}
+	}
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && s[i+1] == '\n':
+			flush()
+			nodes = append(nodes, &mdInline{kind: mdHardBreak})
+			i += 2
+			for i < len(s) && s[i] == ' ' {
+				i++
+			}
+		case c == '\\' && i+1 < len(s) && strings.IndexByte(mdPunctuation, s[i+1]) >= 0:
+			text.WriteByte(s[i+1])
+			i += 2
+		case c == '`':
+			j := i
+			for j < len(s) && s[j] == '`' {
+				j++
+			}
+			fence := s[i:j]
+			end := -1
+			for k := j; k < len(s); {
+				n := strings.Index(s[k:], fence)
+				if n < 0 {
+					break
+				}
+
+				k += n
+				e := k + len(fence)
+				if e < len(s) && s[e] == '`' {
+					for k = e; k < len(s) && s[k] == '`'; k++ {
+					}
+					continue
+				}
+
+				end = k
+				break
+			}
+			if end < 0 {
+				text.WriteString(fence)
+				i = j
+				break
+			}
+
+			code := strings.ReplaceAll(s[j:end], "\n", " ")
+			if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+				code = code[1 : len(code)-1]
+			}
+			flush()
+			nodes = append(nodes, &mdInline{kind: mdCodeSpan, text: code})
+			i = end + len(fence)
+		case c == '*' || c == '_' || c == '~':
+			j := i
+			for j < len(s) && s[j] == c {
+				j++
+			}
+			if c == '~' && j-i > 2 {
+				text.WriteString(s[i:j])
+				i = j
+				break
+			}
+
+			before, after := mdRuneBefore(s, i), mdRuneAt(s, j)
+			leftFlanking := !unicode.IsSpace(after) && (!mdIsPunct(after) || unicode.IsSpace(before) || mdIsPunct(before))
+			rightFlanking := !unicode.IsSpace(before) && (!mdIsPunct(before) || unicode.IsSpace(after) || mdIsPunct(after))
+			canOpen, canClose := leftFlanking, rightFlanking
+			if c == '_' {
+				canOpen = leftFlanking && (!rightFlanking || mdIsPunct(before))
+				canClose = rightFlanking && (!leftFlanking || mdIsPunct(after))
+			}
+			flush()
+			nodes = append(nodes, &mdInline{kind: mdText, text: s[i:j], delim: c, count: j - i, canOpen: canOpen, canClose: canClose})
+			i = j
+		case c == '!' && i+1 < len(s) && s[i+1] == '[':
+			flush()
+			nodes = append(nodes, &mdInline{kind: mdText, text: "![", bracket: true, active: true, pos: i + 2})
+			i += 2
+		case c == '[':
+			flush()
+			nodes = append(nodes, &mdInline{kind: mdText, text: "[", bracket: true, active: true, pos: i + 1})
+			i++
+		case c == ']':
+			flush()
+			nodes, i = d.closeBracket(nodes, s, i)
+		case c == '<':
+			if m := mdAutolinkRe.FindStringSubmatch(s[i:]); m != nil {
+				flush()
+				nodes = append(nodes, &mdInline{kind: mdLink, href: m[1], children: []*mdInline{{kind: mdText, text: m[1]}}})
+				i += len(m[0])
+				break
+			}
+
+			if m := mdEmailRe.FindStringSubmatch(s[i:]); m != nil {
+				flush()
+				nodes = append(nodes, &mdInline{kind: mdLink, href: "mailto:" + m[1], children: []*mdInline{{kind: mdText, text: m[1]}}})
+				i += len(m[0])
+				break
+			}
+
+			text.WriteByte(c)
+			i++
+		case (c == 'h' || c == 'w') && !d.inLink(nodes) && mdBareURLRe.MatchString(s[i:]) && !unicode.IsLetter(mdRuneBefore(s, i)) && !unicode.IsDigit(mdRuneBefore(s, i)):
+			u := mdBareURLRe.FindString(s[i:])
+			// Drop unbalanced closing parentheses at the end.
+			for strings.HasSuffix(u, ")") && strings.Count(u, ")") > strings.Count(u, "(") {
+				u = u[:len(u)-1]
+			}
+			href := u
+			if strings.HasPrefix(u, "www.") {
+				href = "http://" + u
+			}
+			flush()
+			nodes = append(nodes, &mdInline{kind: mdLink, href: href, children: []*mdInline{{kind: mdText, text: u}}})
+			i += len(u)
+		case c == '&':
+			if m := mdEntityRe.FindString(s[i:]); m != "" {
+				text.WriteString(html.UnescapeString(m))
+				i += len(m)
+				break
+			}
+
+			text.WriteByte(c)
+			i++
+		case c == '\n':
+			t := text.String()
+			hard := strings.HasSuffix(t, "  ")
+			text.Reset()
+			text.WriteString(strings.TrimRight(t, " "))
+			flush()
+			switch {
+			case hard:
+				nodes = append(nodes, &mdInline{kind: mdHardBreak})
+			default:
+				nodes = append(nodes, &mdInline{kind: mdSoftBreak})
+			}
+			for i++; i < len(s) && s[i] == ' '; i++ {
+			}
+		default:
+			text.WriteByte(c)
+			i++
+		}
+	}
+	flush()
+	return mdEmphasis(nodes)
+}
+
+// inLink reports whether there is an active link opener in 'nodes'.
+func (d *mdDoc) inLink(nodes []*mdInline) bool {
+	for _, v := range nodes {
+		if v.bracket && v.active && v.text == "[" {
+			return true
+		}
+	}
+	return false
+}
+
+// closeBracket handles a ']' at s[i].
+func (d *mdDoc) closeBracket(nodes []*mdInline, s string, i int) ([]*mdInline, int) {
+	o := -1
+	for j := len(nodes) - 1; j >= 0; j-- {
+		if nodes[j].bracket {
+			o = j
+			break
+		}
+	}
+	if o < 0 {
+		return append(nodes, &mdInline{kind: mdText, text: "]"}), i + 1
+	}
+
+	opener := nodes[o]
+	if !opener.active {
+		opener.bracket = false
+		return append(nodes, &mdInline{kind: mdText, text: "]"}), i + 1
+	}
+
+	href, title, next, ok := d.linkTail(s, i+1, s[opener.pos:i])
+	if !ok {
+		opener.bracket = false
+		return append(nodes, &mdInline{kind: mdText, text: "]"}), i + 1
+	}
+
+	n := &mdInline{kind: mdLink, href: href, title: title, children: mdEmphasis(nodes[o+1:])}
+	if opener.text == "![" {
+		n.kind = mdImage
+	}
+	nodes = append(nodes[:o:o], n)
+	if n.kind == mdLink {
+		// Links may not contain other links.
+		for _, v := range nodes {
+			if v.bracket && v.text == "[" {
+				v.active = false
+			}
+		}
+	}
+	return nodes, next
+}
+
+// linkTail parses what follows the closing bracket of a link at s[i]. Label
+// is the link text used by collapsed and shortcut references.
+func (d *mdDoc) linkTail(s string, i int, label string) (href, title string, next int, ok bool) {
+	if i < len(s) && s[i] == '(' {
+		if href, title, next, ok = mdInlineLink(s, i+1); ok {
+			return href, title, next, ok
+		}
+	}
+
+	if i < len(s) && s[i] == '[' {
+		if j := strings.IndexByte(s[i+1:], ']'); j >= 0 {
+			ref := s[i+1 : i+1+j]
+			if ref == "" {
+				ref = label
+			}
+			if r, ok := d.refs[mdNormalizeLabel(ref)]; ok {
+				return r.href, r.title, i + j + 2, true
+			}
+
+			if s[i+1:i+1+j] != "" {
+				return "", "", 0, false
+			}
+		}
+	}
+
+	if r, ok := d.refs[mdNormalizeLabel(label)]; ok {
+		return r.href, r.title, i, true
+	}
+
+	return "", "", 0, false
+}
+
+// mdInlineLink parses an inline link destination and title starting at
+// s[i], just after the opening parenthesis.
+func mdInlineLink(s string, i int) (href, title string, next int, ok bool) {
+	skip := func() {
+		for i < len(s) && (s[i] == ' ' || s[i] == '\n') {
+			i++
+		}
+	}
+	skip()
+	switch {
+	case i < len(s) && s[i] == '<':
+		j := strings.IndexAny(s[i+1:], ">\n")
+		if j < 0 || s[i+1+j] != '>' {
+			return "", "", 0, false
+		}
+
+		href = s[i+1 : i+1+j]
+		i += j + 2
+	default:
+		start, depth := i, 0
+	loop:
+		for ; i < len(s); i++ {
+			switch c := s[i]; {
+			case c == '\\' && i+1 < len(s):
+				i++
+			case c == '(':
+				depth++
+			case c == ')':
+				if depth == 0 {
+					break loop
+				}
+
+				depth--
+			case c <= ' ':
+				break loop
+			}
+		}
+		if depth != 0 {
+			return "", "", 0, false
+		}
+
+		href = s[start:i]
+	}
+	n := i
+	skip()
+	if i < len(s) && i > n {
+		var closer byte
+		switch s[i] {
+		case '"':
+			closer = '"'
+		case '\'':
+			closer = '\''
+		case '(':
+			closer = ')'
+		}
+		if closer != 0 {
+			j := i + 1
+			for ; j < len(s) && s[j] != closer; j++ {
+				if s[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(s) {
+				return "", "", 0, false
+			}
+
+			title = s[i+1 : j]
+			i = j + 1
+			skip()
+		}
+	}
+	if i >= len(s) || s[i] != ')' {
+		return "", "", 0, false
+	}
+
+	return mdUnescape(href), mdUnescape(title), i + 1, true
+}
+
+// mdEmphasis resolves emphasis and strikethrough delimiter runs in 'nodes'.
+func mdEmphasis(nodes []*mdInline) []*mdInline {
+	for c := 0; c < len(nodes); c++ {
+		closer := nodes[c]
+		if closer.delim == 0 || !closer.canClose || closer.count == 0 {
+			continue
+		}
+
+		o := -1
+		for j := c - 1; j >= 0; j-- {
+			opener := nodes[j]
+			if opener.delim != closer.delim || !opener.canOpen || opener.count == 0 {
+				continue
+			}
+
+			if closer.delim == '~' {
+				if opener.count != closer.count {
+					continue
+				}
+			} else if (opener.canClose || closer.canOpen) && (opener.count+closer.count)%3 == 0 && (opener.count%3 != 0 || closer.count%3 != 0) {
+				continue
+			}
+
+			o = j
+			break
+		}
+		if o < 0 {
+			if !closer.canOpen {
+				closer.delim = 0
+			}
+			continue
+		}
+
+		opener := nodes[o]
+		n := &mdInline{kind: mdEmph}
+		use := 1
+		switch {
+		case closer.delim == '~':
+			n.kind = mdDel
+			use = closer.count
+		case opener.count >= 2 && closer.count >= 2:
+			n.kind = mdStrong
+			use = 2
+		}
+		for _, v := range nodes[o+1 : c] {
+			if v.delim != 0 {
+				v.delim = 0 // Unmatched delimiters inside become literal text.
+			}
+		}
+		n.children = append([]*mdInline(nil), nodes[o+1:c]...)
+		opener.count -= use
+		opener.text = opener.text[:opener.count]
+		closer.count -= use
+		closer.text = closer.text[:closer.count]
+		var r []*mdInline
+		r = append(r, nodes[:o]...)
+		if opener.count != 0 {
+			r = append(r, opener)
+		}
+		r = append(r, n)
+		next := len(r) - 1 // Continue after n or with the rest of the closer.
+		if closer.count != 0 {
+			r = append(r, closer)
+		}
+		r = append(r, nodes[c+1:]...)
+		nodes, c = r, next
+	}
+	return nodes
+}
+
+// mdPlainText returns the text content of 'nodes'.
+func mdPlainText(nodes []*mdInline) string {
+	var b strings.Builder
+	var f func([]*mdInline)
+	f = func(nodes []*mdInline) {
+		for _, v := range nodes {
+			switch v.kind {
+			case mdSoftBreak:
+				b.WriteByte(' ')
+			case mdHardBreak:
+				b.WriteByte('\n')
+			case mdText, mdCodeSpan:
+				b.WriteString(v.text)
+			default:
+				f(v.children)
+			}
+		}
+	}
+	f(nodes)
+	return b.String()
+}
+
+// Text — Create and manipulate 'text' hypertext editing widgets
+//
+// # Description
+//
+// InsertMarkdown renders 'src', a CommonMark document, at the end of 'w'.
+//
+// Supported are headings, paragraphs, emphasis, strong emphasis,
+// strikethrough, inline code, fenced and indented code blocks, block quotes,
+// nested ordered and unordered lists, thematic breaks, links, autolinks,
+// link reference definitions, images and GFM tables.
+//
+// The content is styled using the tags h1 to h6, b, i, bi, del, code, pre, a,
+// blockquote and hr. Tags not already existing in 'w' are configured with
+// defaults derived from the widget font. To change their appearance, call
+// [TextWidget.TagConfigure] before InsertMarkdown. Code uses [CourierFont].
+//
+// Clicking a link calls the handler set by [TextWidget.SetLinkHandler].
+//
+// The destination of an image, like in '![logo](img1)', is the name of an
+// existing Tk image, as returned by [Img.String], or a path to an image file.
+// If neither exists, or the file cannot be loaded, the image alt text is
+// inserted instead. Image files are loaded once per widget and the images are
+// deleted when the widget is destroyed.
+//
+// Tables are inserted as embedded windows.
+func (w *TextWidget) InsertMarkdown(src string) {
+	doc := parseMarkdown(src)
+	r := &mdRenderer{textRenderer: newTextRenderer(w, "end"), doc: doc}
+	defer r.close()
+	r.blocks(doc.blocks, true)
+}
+
+// mdRenderer renders a mdDoc into a TextWidget.
+type mdRenderer struct {
+	*textRenderer
+	doc     *mdDoc
+	blockT  []string // Block level tags, like h1 or blockquote.
+	level   int      // Indentation level.
+	bullet  string   // Pending list item bullet.
+	pending int      // Newlines to insert before the next block.
+	started bool     // Something was inserted.
+}
+
+// sep requests 'n' newlines before the next block.
+func (r *mdRenderer) sep(n int) {
+	if r.started {
+		r.pending = max(r.pending, n)
+	}
+}
+
+// lineTags returns the tags for the current line and emits the pending
+// newlines and list bullet, if any.
+func (r *mdRenderer) lineTags() (tags []string) {
+	if r.pending != 0 {
+		r.insert(strings.Repeat("\n", r.pending))
+		r.pending = 0
+	}
+	r.started = true
+	tags = append(tags, r.blockT...)
+	switch {
+	case r.bullet != "":
+		tags = append(tags, r.itemTag(r.level)...)
+		r.insert(r.bullet+" ", tags...)
+		r.bullet = ""
+	default:
+		tags = append(tags, r.indentTag(r.level)...)
+	}
+	return tags
+}
+
+func (r *mdRenderer) blocks(blocks []*mdBlock, loose bool) {
+	for _, b := range blocks {
+		switch {
+		case loose:
+			r.sep(2)
+		default:
+			r.sep(1)
+		}
+		r.block(b)
+	}
+}
+
+func (r *mdRenderer) block(b *mdBlock) {
+	switch b.kind {
+	case mdParagraph:
+		tags := r.lineTags()
+		r.inlines(r.doc.inlines(strings.TrimRight(strings.Join(b.lines, "\n"), " ")), tags, "")
+	case mdHeading:
+		hd := r.tag(fmt.Sprintf("h%d", b.level))
+		r.blockT = append(r.blockT, hd)
+		tags := r.lineTags()
+		r.inlines(r.doc.inlines(strings.TrimSpace(strings.Join(b.lines, "\n"))), tags, "")
+		r.blockT = r.blockT[:len(r.blockT)-1]
+	case mdCode:
+		r.blockT = append(r.blockT, r.tag("pre"))
+		tags := r.lineTags()
+		r.insert(strings.Join(b.lines, "\n"), tags...)
+		r.blockT = r.blockT[:len(r.blockT)-1]
+	case mdQuote:
+		r.blockT = append(r.blockT, r.tag("blockquote"))
+		r.level++
+		r.blocks(b.children, true)
+		r.level--
+		r.blockT = r.blockT[:len(r.blockT)-1]
+	case mdList:
+		r.level++
+		n := b.start
+		for _, item := range b.children {
+			switch {
+			case b.ordered:
+				r.bullet = fmt.Sprintf("%d%s", n, item.marker[len(item.marker)-1:])
+				n++
+			default:
+				r.bullet = mdBullets[(r.level-1)%len(mdBullets)]
+			}
+			switch {
+			case b.tight:
+				r.sep(1)
+			default:
+				r.sep(2)
+			}
+			if len(item.children) == 0 {
+				r.lineTags()
+				continue
+			}
+
+			for i, v := range item.children {
+				if i != 0 {
+					switch {
+					case b.tight:
+						r.sep(1)
+					default:
+						r.sep(2)
+					}
+				}
+				r.block(v)
+			}
+			r.bullet = ""
+		}
+		r.level--
+	case mdRule:
+		tags := append(r.lineTags(), r.tag("hr"))
+		r.insert(strings.Repeat("─", 40), tags...)
+	case mdTable:
+		tags := r.lineTags()
+		rows := make([][]string, len(b.rows))
+		for i, row := range b.rows {
+			for _, cell := range row {
+				rows[i] = append(rows[i], mdPlainText(r.doc.inlines(cell)))
+			}
+		}
+		r.table(rows, b.align, true, tags...)
+	}
+}
+
+// inlines renders 'nodes' using the block 'tags'. Link is the tag of the
+// enclosing link, if any.
+func (r *mdRenderer) inlines(nodes []*mdInline, tags []string, link string) {
+	r.inlinesStyled(nodes, tags, link, false, false, false)
+}
+
+func (r *mdRenderer) inlinesStyled(nodes []*mdInline, tags []string, link string, bold, italic, del bool) {
+	heading := false
+	for _, v := range tags {
+		if len(v) == 2 && v[0] == 'h' && v[1] >= '1' && v[1] <= '6' {
+			heading = true
+		}
+	}
+	style := func(extra ...string) (r2 []string) {
+		r2 = append(r2, tags...)
+		switch {
+		case heading:
+			// Heading fonts are already bold, a font tag would reset the size.
+		case bold && italic:
+			r2 = append(r2, r.tag("bi"))
+		case bold:
+			r2 = append(r2, r.tag("b"))
+		case italic:
+			r2 = append(r2, r.tag("i"))
+		}
+		if del {
+			r2 = append(r2, r.tag("del"))
+		}
+		if link != "" {
+			r2 = append(r2, r.tag("a"), link)
+		}
+		return append(r2, extra...)
+	}
+	for _, v := range nodes {
+		switch v.kind {
+		case mdText:
+			r.insert(v.text, style()...)
+		case mdSoftBreak:
+			r.insert(" ", style()...)
+		case mdHardBreak:
+			r.insert("\n", style()...)
+		case mdCodeSpan:
+			r.insert(v.text, style(r.tag("code"))...)
+		case mdEmph:
+			r.inlinesStyled(v.children, tags, link, bold, true, del)
+		case mdStrong:
+			r.inlinesStyled(v.children, tags, link, true, italic, del)
+		case mdDel:
+			r.inlinesStyled(v.children, tags, link, bold, italic, true)
+		case mdLink:
+			r.inlinesStyled(v.children, tags, r.link(v.href), bold, italic, del)
+		case mdImage:
+			switch {
+			case isImage(v.href):
+				r.image(v.href, nil, style()...)
+			default:
+				if img, err := mdFileImage(r.w, v.href); err == nil {
+					r.image(img, nil, style()...)
+					break
+				}
+
+				r.insert(mdPlainText(v.children), style()...)
+			}
+		}
+	}
+}
+
+func mdIsFile(name string) bool {
+	fi, err := os.Stat(name)
+	return err == nil && fi.Mode().IsRegular()
+}
+
+// mdFileImage returns the name of the Tk image loaded from the file 'name'
+// for 'w'. Each file is loaded once per widget, the images are deleted when
+// 'w' is destroyed.
+func mdFileImage(w *TextWidget, name string) (string, error) {
+	m := mdImages[w.Window]
+	if img, ok := m[name]; ok {
+		return img, nil
+	}
+
+	if !mdIsFile(name) {
+		return "", fmt.Errorf("not a file: %s", name)
+	}
+
+	img := fmt.Sprintf("img%v", id.Add(1))
+	if _, err := eval(fmt.Sprintf("image create photo %s -file %s", img, tclSafeString(name))); err != nil {
+		return "", err
+	}
+
+	if m == nil {
+		m = map[string]string{}
+		mdImages[w.Window] = m
+		h := newEventHandler("", func(e *Event) {
+			for _, v := range mdImages[w.Window] {
+				eval(fmt.Sprintf("image delete %s", v))
+			}
+			delete(mdImages, w.Window)
+		})
+		h.w = w.Window
+		evalErr(fmt.Sprintf("bind %s <Destroy> {+eventDispatcher %v}", w, h.id))
+	}
+	m[name] = img
+	return img, nil
+}
@@ -0,0 +1,634 @@
+// Copyright 2025 The tk9.0-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tk9_0 // import "modernc.org/tk9.0"
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	richIndentStep = 16 // Points per indentation level.
+)
+
+var (
+	// TextWidget link handlers, see [TextWidget.SetLinkHandler].
+	linkHandlers = map[*Window]func(href string){}
+)
+
+// Text — Create and manipulate 'text' hypertext editing widgets
+//
+// # Description
+//
+// SetLinkHandler sets the function called when the user clicks a link
+// inserted into 'w' by [TextWidget.InsertMarkdown] or
+// [TextWidget.InsertML]. The handler receives the
+// link destination as written in the source. Passing nil removes the handler
+// and clicking links does nothing. The handler is removed when 'w' is
+// destroyed.
+func (w *TextWidget) SetLinkHandler(handler func(href string)) {
+	switch {
+	case handler == nil:
+		delete(linkHandlers, w.Window)
+	default:
+		if _, ok := linkHandlers[w.Window]; !ok {
+			h := newEventHandler("", func(e *Event) { delete(linkHandlers, w.Window) })
+			h.w = w.Window
+			evalErr(fmt.Sprintf("bind %s <Destroy> {+eventDispatcher %v}", w, h.id))
+		}
+		linkHandlers[w.Window] = handler
+	}
+}
+
+// textRenderer inserts styled content into a TextWidget. Tags used by the
+// renderer get a default configuration unless they already exist in the
+// widget, so users can restyle any of them beforehand.
+type textRenderer struct {
+	w        *TextWidget
+	index    string          // Mark with right gravity where to insert.
+	start    string          // Mark with left gravity at the insertion start.
+	cursor   string          // Cursor of w, restored when leaving a link.
+	existing map[string]bool // Tags existing in w before the renderer started.
+	seen     map[string]bool // Tags already handled by tag().
+	family   string          // Font family of w.
+	size     int             // Font size of w, negative values are pixels.
+}
+
+// newTextRenderer returns a renderer inserting content at 'index' of 'w'.
+// Call close when done.
+func newTextRenderer(w *TextWidget, index string) (r *textRenderer) {
+	n := id.Add(1)
+	r = &textRenderer{
+		w:        w,
+		index:    fmt.Sprintf("tk9end%d", n),
+		start:    fmt.Sprintf("tk9start%d", n),
+		existing: map[string]bool{},
+		seen:     map[string]bool{},
+		size:     10,
+	}
+	index = evalErr(fmt.Sprintf("%s index %s", w, tclSafeString(index)))
+	if evalErr(fmt.Sprintf("%s compare %s == end", w, index)) == "1" {
+		// Text cannot be inserted after the final newline.
+		index = evalErr(fmt.Sprintf("%s index {end -1 chars}", w))
+	}
+	evalErr(fmt.Sprintf("%s mark set %s %s", w, r.start, index))
+	evalErr(fmt.Sprintf("%s mark gravity %s left", w, r.start))
+	evalErr(fmt.Sprintf("%s mark set %s %s", w, r.index, index))
+	evalErr(fmt.Sprintf("%s mark gravity %s right", w, r.index))
+	for _, v := range w.TagNames("") {
+		r.existing[v] = true
+	}
+	a := parseList(evalErr(fmt.Sprintf("font actual [%s cget -font]", w)))
+	for i := 0; i+1 < len(a); i += 2 {
+		switch a[i] {
+		case "-family":
+			r.family = a[i+1]
+		case "-size":
+			r.size = atoi(a[i+1])
+		}
+	}
+	r.cursor = evalErr(fmt.Sprintf("%s cget -cursor", w))
+	return r
+}
+
+// font returns a -font option using the font family of the widget, its size
+// multiplied by k and the style modifiers, like "bold" or "italic".
+func (r *textRenderer) font(family string, k float64, style ...string) Opt {
+	if family == "" {
+		family = r.family
+	}
+	size := int(math.Round(float64(r.size) * k))
+	if size == 0 {
+		size = r.size
+	}
+	return rawOption(fmt.Sprintf("-font [list %s %d %s]", tclSafeString(family), size, tclSafeStrings(style...)))
+}
+
+// tag returns 'name' after making sure the tag is configured.
+func (r *textRenderer) tag(name string) string {
+	if r.seen[name] {
+		return name
+	}
+
+	r.seen[name] = true
+	if r.existing[name] {
+		return name
+	}
+
+	var opts Opts
+	switch name {
+	case "h1":
+		opts = Opts{r.font("", 2, "bold"), Spacing1("6p"), Spacing3("6p")}
+	case "h2":
+		opts = Opts{r.font("", 1.5, "bold"), Spacing1("5p"), Spacing3("5p")}
+	case "h3":
+		opts = Opts{r.font("", 1.25, "bold"), Spacing1("4p"), Spacing3("4p")}
+	case "h4":
+		opts = Opts{r.font("", 1.1, "bold"), Spacing1("3p"), Spacing3("3p")}
+	case "h5":
+		opts = Opts{r.font("", 1, "bold"), Spacing1("2p"), Spacing3("2p")}
+	case "h6":
+		opts = Opts{r.font("", 0.9, "bold"), Foreground("#59636e"), Spacing1("2p"), Spacing3("2p")}
+	case "b", "strong":
+		opts = Opts{r.font("", 1, "bold")}
+	case "i", "em":
+		opts = Opts{r.font("", 1, "italic")}
+	case "bi":
+		opts = Opts{r.font("", 1, "bold", "italic")}
+	case "u":
+		opts = Opts{Underline(1)}
+	case "del":
+		opts = Opts{Overstrike(1)}
+	case "code":
+		opts = Opts{r.font(CourierFont(), 1), Background("#eff1f3")}
+	case "pre":
+		opts = Opts{r.font(CourierFont(), 1), Background("#eff1f3"), Wrap("none")}
+	case "a":
+		opts = Opts{Foreground("#0969da"), Underline(1)}
+	case "blockquote":
+		opts = Opts{Foreground("#59636e")}
+	case "hr":
+		opts = Opts{Foreground("#d1d9e0"), Justify("center")}
+	default:
+		var n int
+		switch {
+		case strings.HasPrefix(name, "indent"):
+			if _, err := fmt.Sscanf(name, "indent%d", &n); err == nil {
+				m := fmt.Sprintf("%dp", n*richIndentStep)
+				opts = Opts{Lmargin1(m), Lmargin2(m)}
+			}
+		case strings.HasPrefix(name, "li"):
+			if _, err := fmt.Sscanf(name, "li%d", &n); err == nil {
+				opts = Opts{Lmargin1(fmt.Sprintf("%dp", (n-1)*richIndentStep+richIndentStep/4)), Lmargin2(fmt.Sprintf("%dp", n*richIndentStep))}
+			}
+		}
+	}
+	if len(opts) != 0 {
+		r.w.TagConfigure(name, opts...)
+	}
+	return name
+}
+
+// indentTag returns the tag indenting text at nesting 'level'. Level zero has
+// no tag.
+func (r *textRenderer) indentTag(level int) []string {
+	if level <= 0 {
+		return nil
+	}
+
+	return []string{r.tag(fmt.Sprintf("indent%d", level))}
+}
+
+// itemTag returns the tag for the first line of a list item at nesting
+// 'level', which produces a hanging indent for the bullet.
+func (r *textRenderer) itemTag(level int) []string {
+	return []string{r.tag(fmt.Sprintf("li%d", max(level, 1)))}
+}
+
+func tclTagList(tags []string) string {
+	return fmt.Sprintf("[list %s]", tclSafeStrings(tags...))
+}
+
+func (r *textRenderer) insert(s string, tags ...string) {
+	if s == "" {
+		return
+	}
+
+	evalErr(fmt.Sprintf("%s insert %s %s %s", r.w, r.index, tclSafeString(s), tclTagList(tags)))
+}
+
+// image inserts the Tk image 'img'. The tags are applied to the image
+// position.
+func (r *textRenderer) image(img string, opts []string, tags ...string) {
+	idx := evalErr(fmt.Sprintf("%s index %s", r.w, r.index))
+	evalErr(fmt.Sprintf("%s image create %s -image %s %s", r.w, r.index, tclSafeString(img), strings.Join(opts, " ")))
+	r.tagAt(idx, tags)
+}
+
+// window inserts the embedded window 'win'. The tags are applied to the
+// window position.
+func (r *textRenderer) window(win string, opts []string, tags ...string) {
+	idx := evalErr(fmt.Sprintf("%s index %s", r.w, r.index))
+	evalErr(fmt.Sprintf("%s window create %s -window %s %s", r.w, r.index, win, strings.Join(opts, " ")))
+	r.tagAt(idx, tags)
+}
+
+// finish returns the indices of the inserted content.
+func (r *textRenderer) finish() (start, end string) {
+	start = evalErr(fmt.Sprintf("%s index %s", r.w, r.start))
+	end = evalErr(fmt.Sprintf("%s index %s", r.w, r.index))
+	return start, end
+}
+
+// close removes the marks used by the renderer. It is deferred by the
+// callers of newTextRenderer so the marks do not leak when rendering fails
+// midway.
+func (r *textRenderer) close() {
+	eval(fmt.Sprintf("%s mark unset %s %s", r.w, r.start, r.index))
+}
+
+func (r *textRenderer) tagAt(idx string, tags []string) {
+	for _, v := range tags {
+		evalErr(fmt.Sprintf("%s tag add %s %s", r.w, tclSafeString(v), idx))
+	}
+}
+
+// isImage reports whether 'name' is an existing Tk image.
+func isImage(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	_, err := eval(fmt.Sprintf("image type %s", tclSafeString(name)))
+	return err == nil
+}
+
+// bol reports whether the insertion point is at the beginning of a line.
+func (r *textRenderer) bol() bool {
+	return evalErr(fmt.Sprintf("%s compare %s == {%[2]s linestart}", r.w, r.index)) == "1"
+}
+
+// newline starts a new line unless the insertion point is already at the
+// beginning of a line.
+func (r *textRenderer) newline() {
+	if !r.bol() {
+		r.insert("\n")
+	}
+}
+
+// link returns a new tag that invokes the link handler of the widget with
+// 'href' when clicked.
+func (r *textRenderer) link(href string) string {
+	tag := fmt.Sprintf("link%d", id.Add(1))
+	w := r.w
+	w.TagBind(tag, "<Button-1>", func() {
+		if h := linkHandlers[w.Window]; h != nil {
+			h(href)
+		}
+	})
+	evalErr(fmt.Sprintf("%s tag bind %s <Enter> [list %[1]s configure -cursor hand2]", w, tag))
+	evalErr(fmt.Sprintf("%s tag bind %s <Leave> [list %[1]s configure -cursor %[3]s]", w, tag, tclSafeString(r.cursor)))
+	return tag
+}
+
+// table inserts 'rows' as an embedded frame with a grid of labels. The
+// first row is the header when 'header' is true. Align items are "left",
+// "center", "right" or "" for the default.
+func (r *textRenderer) table(rows [][]string, align []string, header bool, tags ...string) {
+	f := r.w.Window.Frame(Borderwidth(0))
+	for i, row := range rows {
+		for j, cell := range row {
+			anchor := "w"
+			if j < len(align) {
+				switch align[j] {
+				case "center":
+					anchor = "center"
+				case "right":
+					anchor = "e"
+				}
+			}
+			opts := Opts{Txt(cell), Anchor(anchor), Justify("left"), Relief("solid"), Borderwidth(1), Padx("4p"), Pady("2p")}
+			if header && i == 0 {
+				opts = append(opts, r.font("", 1, "bold"))
+			}
+			Grid(f.Label(opts...), Row(i), Column(j), Sticky("nsew"))
+		}
+	}
+	r.window(f.String(), []string{"-align", "top", "-padx", "2p", "-pady", "2p"}, tags...)
+}
+
+// ML tags having a default configuration.
+var mlStyled = map[string]bool{
+	"a":      true,
+	"b":      true,
+	"code":   true,
+	"del":    true,
+	"em":     true,
+	"h1":     true,
+	"h2":     true,
+	"h3":     true,
+	"h4":     true,
+	"h5":     true,
+	"h6":     true,
+	"i":      true,
+	"strong": true,
+	"u":      true,
+}
+
+// mlRenderer renders the parsed markup of [TextWidget.InsertML].
+type mlRenderer struct {
+	*textRenderer
+	k     float64 // TeX scale.
+	level int     // List nesting level.
+	trim  bool    // Drop leading white space of the next text.
+}
+
+// withTags returns 'tags' extended by 'more' without modifying 'tags'.
+func withTags(tags []string, more ...string) []string {
+	return append(tags[:len(tags):len(tags)], more...)
+}
+
+func mlAttr(n *html.Node, key string) (r string, ok bool) {
+	for _, v := range n.Attr {
+		if v.Key == key {
+			return v.Val, true
+		}
+	}
+	return "", false
+}
+
+func (r *mlRenderer) children(n *html.Node, tags []string) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r.node(c, tags)
+	}
+}
+
+func (r *mlRenderer) node(n *html.Node, tags []string) {
+	switch n.Type {
+	case html.DocumentNode:
+		r.children(n, tags)
+	case html.TextNode:
+		r.text(n.Data, tags)
+	case html.ElementNode:
+		if s, ok := mlAttr(n, "style"); ok {
+			if tag := r.styleTag(s); tag != "" {
+				tags = withTags(tags, tag)
+			}
+		}
+		switch n.Data {
+		case "html", "head", "body":
+			r.children(n, tags)
+		case "br":
+			r.insert("\n", tags...)
+		case "img", "embed":
+			var src string
+			var opts []string
+			for _, v := range n.Attr {
+				switch v.Key {
+				case "src":
+					src = v.Val
+				case "opt":
+					opts = append(opts, v.Val)
+				}
+			}
+			switch n.Data {
+			case "img":
+				r.image(src, opts, tags...)
+			default:
+				r.window(src, opts, tags...)
+			}
+			r.trim = false
+		case "a":
+			tags = withTags(tags, r.tag("a"))
+			if href, ok := mlAttr(n, "href"); ok {
+				tags = withTags(tags, r.link(href))
+			}
+			r.children(n, tags)
+		case "ul", "ol":
+			r.list(n, tags)
+		case "table":
+			r.table(n, tags)
+		case "h1", "h2", "h3", "h4", "h5", "h6":
+			r.newline()
+			r.trim = true
+			r.children(n, withTags(tags, r.tag(n.Data)))
+			r.newline()
+			r.trim = true
+		default:
+			if mlStyled[n.Data] {
+				r.tag(n.Data)
+			}
+			r.children(n, withTags(tags, n.Data))
+		}
+	}
+}
+
+func (r *mlRenderer) text(s string, tags []string) {
+	if r.level != 0 {
+		tags = withTags(tags, r.itemTag(r.level)...)
+	}
+	var bold, italic bool
+	for _, v := range tags {
+		switch v {
+		case "pre":
+			r.insert(unescapeML(s), tags...)
+			r.trim = false
+			return
+		case "b", "strong":
+			bold = true
+		case "i", "em":
+			italic = true
+		}
+	}
+	if bold && italic {
+		tags = withTags(tags, r.tag("bi"))
+	}
+	if r.trim {
+		if s = strings.TrimLeft(s, " \t\r\n"); s == "" {
+			return
+		}
+
+		r.trim = false
+	}
+
+	ids, toks := tokenize(s)
+	for i, id := range ids {
+		switch id {
+		default:
+			if s := toks[i]; strings.HasPrefix(s, "$$") && strings.HasSuffix(s, "$$") || strings.HasPrefix(s, "$") && strings.HasSuffix(s, "$") {
+				r.image(NewPhoto(Data(TeX(s, r.k))).String(), []string{"-align", "top"}, tags...)
+				break
+			}
+
+			fallthrough
+		case 0:
+			evalErr(fmt.Sprintf("%s insert %s %s %s", r.w, r.index, tclFromElementNode(unescapeML(toks[i])), tclTagList(tags)))
+		}
+	}
+}
+
+// list renders an <ul> or <ol> element.
+func (r *mlRenderer) list(n *html.Node, tags []string) {
+	r.newline()
+	r.level++
+	num := 1
+	if s, ok := mlAttr(n, "start"); ok {
+		num = atoi(s)
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch {
+		case c.Type == html.ElementNode && c.Data == "li":
+			r.newline()
+			bullet := mdBullets[(r.level-1)%len(mdBullets)]
+			if n.Data == "ol" {
+				bullet = fmt.Sprintf("%d.", num)
+				num++
+			}
+			r.insert(bullet+" ", withTags(tags, r.itemTag(r.level)...)...)
+			r.trim = true
+			r.children(c, tags)
+		case c.Type == html.TextNode && strings.TrimSpace(c.Data) == "":
+			// White space between the items.
+		default:
+			r.node(c, tags)
+		}
+	}
+	r.level--
+	r.newline()
+	r.trim = true
+}
+
+// table renders a <table> element.
+func (r *mlRenderer) table(n *html.Node, tags []string) {
+	var rows [][]string
+	var align []string
+	header := false
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+
+			switch c.Data {
+			case "tr":
+				var row []string
+				allTh := true
+				for d := c.FirstChild; d != nil; d = d.NextSibling {
+					if d.Type != html.ElementNode || d.Data != "td" && d.Data != "th" {
+						continue
+					}
+
+					allTh = allTh && d.Data == "th"
+					row = append(row, strings.Join(strings.Fields(unescapeML(mlPlainText(d))), " "))
+					if len(rows) == 0 {
+						align = append(align, mlAlign(d))
+					}
+				}
+				if len(rows) == 0 {
+					header = allTh && len(row) != 0
+				}
+				rows = append(rows, row)
+			case "table":
+				// Nested tables are not supported.
+			default:
+				f(c)
+			}
+		}
+	}
+	f(n)
+	r.newline()
+	if r.level != 0 {
+		tags = withTags(tags, r.indentTag(r.level)...)
+	}
+	r.textRenderer.table(rows, align, header, tags...)
+	r.newline()
+	r.trim = true
+}
+
+// mlAlign returns the horizontal alignment of a table cell.
+func mlAlign(n *html.Node) string {
+	if s, ok := mlAttr(n, "align"); ok {
+		return strings.ToLower(s)
+	}
+
+	if s, ok := mlAttr(n, "style"); ok {
+		for _, v := range mlCSS(s) {
+			if v[0] == "text-align" {
+				return v[1]
+			}
+		}
+	}
+	return ""
+}
+
+func mlPlainText(n *html.Node) string {
+	var b strings.Builder
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch c.Type {
+			case html.TextNode:
+				b.WriteString(c.Data)
+			case html.ElementNode:
+				if c.Data == "br" {
+					b.WriteByte('\n')
+				}
+				f(c)
+			}
+		}
+	}
+	f(n)
+	return b.String()
+}
+
+// mlCSS parses the declarations of a style attribute.
+func mlCSS(s string) (r [][2]string) {
+	for _, v := range strings.Split(s, ";") {
+		k, v, ok := strings.Cut(v, ":")
+		if !ok {
+			continue
+		}
+
+		k, v = strings.ToLower(strings.TrimSpace(k)), strings.TrimSpace(v)
+		if k != "" && v != "" {
+			r = append(r, [2]string{k, v})
+		}
+	}
+	return r
+}
+
+// styleTag returns a tag implementing the style attribute 's' or "" if 's'
+// has no supported properties.
+func (r *mlRenderer) styleTag(s string) string {
+	var opts Opts
+	var keys, font []string
+	for _, v := range mlCSS(s) {
+		k, v := v[0], v[1]
+		switch k {
+		case "color":
+			opts = append(opts, Foreground(v))
+		case "background-color", "background":
+			opts = append(opts, Background(v))
+		case "font-weight":
+			if v == "bold" || v == "bolder" || atoi(v) >= 600 {
+				font = append(font, "bold")
+			}
+		case "font-style":
+			if v == "italic" || v == "oblique" {
+				font = append(font, "italic")
+			}
+		case "text-decoration":
+			for _, w := range strings.Fields(v) {
+				switch w {
+				case "underline":
+					opts = append(opts, Underline(1))
+				case "line-through":
+					opts = append(opts, Overstrike(1))
+				}
+			}
+		default:
+			continue
+		}
+
+		keys = append(keys, k+":"+v)
+	}
+	if len(font) != 0 {
+		opts = append(opts, r.font("", 1, font...))
+	}
+	if len(opts) == 0 {
+		return ""
+	}
+
+	tag := "style{" + strings.Join(keys, ";") + "}"
+	if !r.seen[tag] {
+		r.seen[tag] = true
+		r.w.TagConfigure(tag, opts...)
+	}
+	return tag
+}
@@ -50,8 +50,8 @@ type fntkey struct {
 }
 
 type renderer struct {
-	bkg   color.Color
 	bound image.Rectangle
+	color *texColorHandler
 	ctx   kpath.Context
 	err   error
 	faces map[fntkey]font.Face
@@ -69,8 +69,8 @@ type renderer struct {
 	bounded bool
 }
 
-func newRenderer(ctx kpath.Context, scale float64) *renderer {
-	return &renderer{ctx: ctx, faces: make(map[fntkey]font.Face), scale: scale}
+func newRenderer(ctx kpath.Context, scale float64, color *texColorHandler) *renderer {
+	return &renderer{ctx: ctx, faces: make(map[fntkey]font.Face), scale: scale, color: color}
 }
 
 func (pr *renderer) Init(pre *dvi.CmdPre, post *dvi.CmdPost) {
@@ -84,9 +84,6 @@ func (pr *renderer) Init(pre *dvi.CmdPre, post *dvi.CmdPost) {
 	pr.tconv = conv
 	pr.conv = conv * float32(pr.pre.Mag) / 1000.0
 	conv = 1/(float32(pre.Num)/float32(pre.Den)*(float32(pre.Mag)/1000.0)*(pr.dpi*shrink/254000.0)) + 0.5
-	if pr.bkg == nil {
-		pr.bkg = color.Transparent
-	}
 }
 
 func (pr *renderer) BOP(bop *dvi.CmdBOP) {
@@ -96,8 +93,7 @@ func (pr *renderer) BOP(bop *dvi.CmdBOP) {
 
 	pr.page = int(bop.C0)
 	bnd := image.Rect(0, 0, int(pr.pixels(int32(pr.post.Width))), int(pr.pixels(int32(pr.post.Height))))
-	pr.img = image.NewRGBA(bnd)
-	draw.Draw(pr.img, bnd, image.NewUniform(pr.bkg), image.Point{}, draw.Over)
+	pr.img = image.NewRGBA(bnd) // Transparent, the background is applied in EOP.
 }
 
 func (pr *renderer) DrawGlyph(x, y int32, font dvi.Font, glyph rune, c color.Color) {
@@ -147,6 +143,12 @@ func (pr *renderer) EOP() {
 	}
 
 	pr.final = pr.img.SubImage(pr.bound)
+	if bkg := pr.color.Background(); bkg != color.Transparent {
+		img := image.NewRGBA(pr.bound)
+		draw.Draw(img, pr.bound, image.NewUniform(bkg), image.Point{}, draw.Src)
+		draw.Draw(img, pr.bound, pr.final, pr.bound.Min, draw.Over)
+		pr.final = img
+	}
 	if pr.scale != 1.0 {
 		pr.final = imaging.Resize(pr.final, int(float64(pr.bound.Max.X-pr.bound.Min.X)*pr.scale+0.5), 0, imaging.Lanczos)
 	}
@@ -218,8 +220,8 @@ func roundF32(v float32) int32 {
 	return int32(v - 0.5)
 }
 
-func dvi2png(r io.Reader, scale float64) (_ []byte, err error) {
-	img, err := dvi2img(r, scale)
+func dvi2png(r io.Reader, scale float64, cfg *TeXConfig) (_ []byte, err error) {
+	img, err := dvi2img(r, scale, cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -232,13 +234,14 @@ func dvi2png(r io.Reader, scale float64) (_ []byte, err error) {
 	return out.Bytes(), nil
 }
 
-func dvi2img(r io.Reader, scale float64) (img image.Image, err error) {
+func dvi2img(r io.Reader, scale float64, cfg *TeXConfig) (img image.Image, err error) {
 	ctx := kpath.New()
-	renderer := newRenderer(ctx, scale)
+	color := newTeXColorHandler(cfg)
+	renderer := newRenderer(ctx, scale, color)
 	vm := dvi.NewMachine(
 		dvi.WithContext(ctx),
 		dvi.WithRenderer(renderer),
-		dvi.WithHandlers(dvi.NewColorHandler(ctx)),
+		dvi.WithHandlers(color),
 		dvi.WithOffsetX(0),
 		dvi.WithOffsetY(0),
 	)
@@ -265,23 +268,28 @@ func dvi2img(r io.Reader, scale float64) (img image.Image, err error) {
 	return renderer.final, nil
 }
 
-func tex2dvi(src string) (dvi *bytes.Buffer, err error) {
-	// To get rid of the page number rendered by default, the function prepends
-	// "\footline={}\n" to src. Also, "\n\bye\n" is appended to 'src' to make it a
-	// complete TeX document.
-	var stdout, stderr, b bytes.Buffer
-	nm := wmTitle
+// texJobName returns the TeX job name. Must be called from the Tcl thread.
+func texJobName() (r string) {
+	r = wmTitle
 	switch {
-	case nm == "plain":
-		nm += "_"
-	case nm == "":
-		nm = "x"
+	case r == "plain":
+		r += "_"
+	case r == "":
+		r = "x"
 	}
+	return r
+}
+
+func tex2dvi(src, nm, preamble string) (dvi *bytes.Buffer, err error) {
+	// To get rid of the page number rendered by default, the function prepends
+	// "\footline={}\n" and the preamble to src. Also, "\n\bye\n" is appended to
+	// 'src' to make it a complete TeX document.
+	var stdout, stderr, b bytes.Buffer
 	if err = tex.Main(
 		strings.NewReader(fmt.Sprintf("\\input plain \\input %s", nm)),
 		&stdout,
 		&stderr,
-		tex.WithInputFile(nm+".tex", strings.NewReader(fmt.Sprintf("\\footline={}\n%s\n\\bye\n", src))),
+		tex.WithInputFile(nm+".tex", strings.NewReader(fmt.Sprintf("\\footline={}\n%s%s\n\\bye\n", preamble, src))),
 		tex.WithDVIFile(&b),
 		tex.WithLogFile(io.Discard),
 	); err != nil {
@@ -321,17 +329,13 @@ func TeX(src string, scale float64) (png []byte) {
 //
 // Only plain Tex and a subset of some of the default Computer Modern fonts are
 // supported. Many small fonts are not available.
+//
+// Colors and LaTeX-style macros are configured by [SetTeXConfig].
+//
+// Rendered snippets are cached, see [SetTeXCacheSize] and
+// [SetTeXCachePersistent].
 func TeX2(src string, scale float64) (png []byte, err error) {
-	if src = sanitizeTeX(src); src == "" {
-		return nil, fmt.Errorf("empty TeX code")
-	}
-
-	dvi, err := tex2dvi(src)
-	if err != nil {
-		return nil, err
-	}
-
-	return dvi2png(dvi, scale)
+	return texPNG(src, scale, texJobName(), texConfig)
 }
 
 // TeXImg renders is line TeX but returns an [image.Image].
@@ -347,14 +351,5 @@ func TeXImg(src string, scale float64) (img image.Image) {
 
 // TeXImg2 renders is line TeX2 but returns an [image.Image].
 func TeXImg2(src string, scale float64) (img image.Image, err error) {
-	if src = sanitizeTeX(src); src == "" {
-		return nil, fmt.Errorf("empty TeX code")
-	}
-
-	dvi, err := tex2dvi(src)
-	if err != nil {
-		return nil, err
-	}
-
-	return dvi2img(dvi, scale)
+	return texImg(src, scale, texJobName(), texConfig)
 }
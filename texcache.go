@@ -0,0 +1,264 @@
+// Copyright 2025 The tk9.0-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tk9_0 // import "modernc.org/tk9.0"
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTeXCacheSize = 256
+	uiPollInterval      = 20 * time.Millisecond
+
+	// texFontSet identifies the TeX format and fonts used for rendering. It is
+	// part of the cache key so changes in rendering invalidate cached images.
+	texFontSet = "plain/cm"
+)
+
+var (
+	texCache = newTeXCache(defaultTeXCacheSize)
+
+	// texMu serializes TeX runs, tex.Main is not documented to be safe for
+	// concurrent use.
+	texMu sync.Mutex
+
+	uiQueue struct {
+		sync.Mutex
+		fns     []func()
+		pending int // Jobs started but not yet delivered.
+	}
+	uiTicker *Ticker // Non nil while jobs are in flight.
+)
+
+type texCacheEntry struct {
+	key string
+	png []byte
+}
+
+// teXCache is a content addressed cache of rendered TeX snippets. It is safe
+// for concurrent use.
+type teXCache struct {
+	sync.Mutex
+	dir   string // Persistence directory, if not empty.
+	items map[string]*list.Element
+	lru   *list.List
+	size  int
+}
+
+func newTeXCache(size int) *teXCache {
+	return &teXCache{items: map[string]*list.Element{}, lru: list.New(), size: size}
+}
+
+// texKey returns the cache key of a sanitized TeX snippet.
+func texKey(src string, scale float64, cfg *TeXConfig) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(fmt.Sprintf("%s\x00%v\x00%s\x00%s", src, scale, texFontSet, cfg.key()))))
+}
+
+// get returns a copy of the cached PNG image of 'key'.
+func (c *teXCache) get(key string) (r []byte, ok bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		c.lru.MoveToFront(e)
+		return bytes.Clone(e.Value.(*texCacheEntry).png), true
+	}
+
+	if c.dir == "" {
+		return nil, false
+	}
+
+	b, err := os.ReadFile(filepath.Join(c.dir, key+".png"))
+	if err != nil {
+		return nil, false
+	}
+
+	c.add(key, b)
+	return bytes.Clone(b), true
+}
+
+func (c *teXCache) put(key string, b []byte) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.add(key, b)
+	if c.dir != "" {
+		fn := filepath.Join(c.dir, key+".png")
+		tmp := fmt.Sprintf("%s.%d", fn, os.Getpid())
+		if os.WriteFile(tmp, b, 0o600) == nil {
+			if os.Rename(tmp, fn) != nil {
+				os.Remove(tmp)
+			}
+		}
+	}
+}
+
+func (c *teXCache) add(key string, b []byte) {
+	if c.size <= 0 {
+		return
+	}
+
+	if e, ok := c.items[key]; ok {
+		c.lru.MoveToFront(e)
+		return
+	}
+
+	c.items[key] = c.lru.PushFront(&texCacheEntry{key, b})
+	c.trim()
+}
+
+func (c *teXCache) trim() {
+	for c.lru.Len() > max(c.size, 0) {
+		e := c.lru.Back()
+		delete(c.items, e.Value.(*texCacheEntry).key)
+		c.lru.Remove(e)
+	}
+}
+
+// SetTeXCacheSize sets the maximum number of rendered TeX snippets the
+// functions [TeX], [TeX2], [TeXImg], [TeXImg2] and [TeXAsync] keep in memory.
+// The default is 256. Zero disables the in-memory cache.
+//
+// SetTeXCacheSize is safe for concurrent use.
+func SetTeXCacheSize(n int) {
+	texCache.Lock()
+	defer texCache.Unlock()
+
+	texCache.size = n
+	texCache.trim()
+}
+
+// SetTeXCachePersistent enables or disables storing rendered TeX snippets on
+// disk, in the "tex" subdirectory of the user cache directory used by this
+// package. Persisted snippets survive process restarts. Persistence is
+// disabled by default.
+//
+// SetTeXCachePersistent is safe for concurrent use.
+func SetTeXCachePersistent(on bool) (err error) {
+	var dir string
+	if on {
+		if dir, err = os.UserCacheDir(); err != nil {
+			return err
+		}
+
+		dir = filepath.Join(dir, "modernc.org", libVersion, "tex")
+		if err = os.MkdirAll(dir, 0o700); err != nil {
+			return err
+		}
+	}
+
+	texCache.Lock()
+	defer texCache.Unlock()
+
+	texCache.dir = dir
+	return nil
+}
+
+// texPNG returns the cached PNG rendering of 'src' or renders it.
+func texPNG(src string, scale float64, job string, cfg TeXConfig) (r []byte, err error) {
+	if src = sanitizeTeX(src); src == "" {
+		return nil, fmt.Errorf("empty TeX code")
+	}
+
+	key := texKey(src, scale, &cfg)
+	if r, ok := texCache.get(key); ok {
+		return r, nil
+	}
+
+	texMu.Lock()
+	defer texMu.Unlock()
+
+	dvi, err := tex2dvi(src, job, cfg.preamble())
+	if err != nil {
+		return nil, err
+	}
+
+	if r, err = dvi2png(dvi, scale, &cfg); err != nil {
+		return nil, err
+	}
+
+	texCache.put(key, r)
+	return r, nil
+}
+
+// texImg is like texPNG but returns a decoded image.
+func texImg(src string, scale float64, job string, cfg TeXConfig) (r image.Image, err error) {
+	b, err := texPNG(src, scale, job, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return png.Decode(bytes.NewReader(b))
+}
+
+// TeXAsync is like [TeX] but renders 'src' in a separate goroutine. When
+// done, the result is passed to 'handler' as a new photo image, or an error.
+//
+// TeXAsync must be called from the same goroutine as the other functions of
+// this package. The handler is called from that goroutine as well, while the
+// event loop is running, for example from [Window.Wait].
+func TeXAsync(src string, scale float64, handler func(*Img, error)) {
+	job, cfg := texJobName(), texConfig
+	uiStartJob()
+	go func() {
+		b, err := texPNG(src, scale, job, cfg)
+		uiPost(func() {
+			if err != nil {
+				handler(nil, err)
+				return
+			}
+
+			handler(NewPhoto(Data(b)), nil)
+		})
+	}()
+}
+
+// uiStartJob registers a job that will uiPost its result. Must be called from
+// the Tcl thread.
+func uiStartJob() {
+	uiQueue.Lock()
+	uiQueue.pending++
+	uiQueue.Unlock()
+	if uiTicker == nil {
+		var err error
+		if uiTicker, err = NewTicker(uiPollInterval, uiDrain); err != nil {
+			fail(err)
+		}
+	}
+}
+
+// uiPost queues 'f' to be executed on the Tcl thread. Safe for concurrent use.
+func uiPost(f func()) {
+	uiQueue.Lock()
+	uiQueue.fns = append(uiQueue.fns, f)
+	uiQueue.Unlock()
+}
+
+// uiDrain runs the queued functions on the Tcl thread and stops the ticker
+// when there are no more jobs in flight.
+func uiDrain() {
+	uiQueue.Lock()
+	fns := uiQueue.fns
+	uiQueue.fns = nil
+	uiQueue.pending -= len(fns)
+	more := uiQueue.pending > 0
+	uiQueue.Unlock()
+	if !more {
+		uiTicker.Stop()
+		uiTicker = nil
+	}
+	for _, f := range fns {
+		f()
+	}
+}
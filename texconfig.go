@@ -0,0 +1,267 @@
+// Copyright 2025 The tk9.0-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tk9_0 // import "modernc.org/tk9.0"
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"modernc.org/knuth/dvi"
+)
+
+// texLaTeXMacros maps common LaTeX math commands to plain TeX.
+//
+// \mathbb falls back to bold, the blackboard bold fonts are not available.
+// Environments are implemented by macros delimited by \end, so they cannot
+// be nested.
+const texLaTeXMacros = `\def\frac#1#2{{#1\over#2}}
+\def\dfrac#1#2{{\displaystyle{#1\over#2}}}
+\def\tfrac#1#2{{\textstyle{#1\over#2}}}
+\def\binom#1#2{{#1\choose#2}}
+\def\text#1{\hbox{\rm #1}}
+\def\textbf#1{\hbox{\bf #1}}
+\def\textit#1{\hbox{\it #1}}
+\def\mathrm#1{{\rm #1}}
+\def\mathbf#1{{\bf #1}}
+\def\mathit#1{{\it #1}}
+\def\mathcal#1{{\cal #1}}
+\def\mathbb#1{{\bf #1}}
+\def\operatorname#1{\mathop{\rm #1}\nolimits}
+\def\color#1{\special{color push #1}\aftergroup\tkcolorpop}
+\def\tkcolorpop{\special{color pop}}
+\def\textcolor#1#2{{\special{color push #1}#2\special{color pop}}}
+\def\begin#1{\csname begin#1\endcsname}
+\def\beginaligned#1\end#2{\begingroup\let\\=\cr\eqalign{#1}\endgroup}
+\def\begincases#1\end#2{\begingroup\let\\=\cr\cases{#1}\endgroup}
+\def\beginmatrix#1\end#2{\begingroup\let\\=\cr\matrix{#1}\endgroup}
+\def\beginpmatrix#1\end#2{\begingroup\let\\=\cr\pmatrix{#1}\endgroup}
+\def\beginbmatrix#1\end#2{\begingroup\let\\=\cr\left[\matrix{#1}\right]\endgroup}
+`
+
+var (
+	texConfig TeXConfig
+
+	// Color names recognized in color specials, in addition to the numeric
+	// models.
+	texColorNames = map[string]color.RGBA{
+		"black":     {0, 0, 0, 255},
+		"blue":      {0, 0, 255, 255},
+		"brown":     {191, 128, 64, 255},
+		"cyan":      {0, 255, 255, 255},
+		"darkgray":  {64, 64, 64, 255},
+		"gray":      {128, 128, 128, 255},
+		"green":     {0, 255, 0, 255},
+		"lightgray": {191, 191, 191, 255},
+		"lime":      {191, 255, 0, 255},
+		"magenta":   {255, 0, 255, 255},
+		"olive":     {128, 128, 0, 255},
+		"orange":    {255, 128, 0, 255},
+		"pink":      {255, 191, 191, 255},
+		"purple":    {191, 0, 64, 255},
+		"red":       {255, 0, 0, 255},
+		"teal":      {0, 128, 128, 255},
+		"violet":    {128, 0, 128, 255},
+		"white":     {255, 255, 255, 255},
+		"yellow":    {255, 255, 0, 255},
+	}
+)
+
+// TeXConfig configures rendering of TeX snippets by [TeX], [TeX2], [TeXImg],
+// [TeXImg2] and [TeXAsync]. The zero value renders black glyphs on a
+// transparent background using plain TeX only.
+type TeXConfig struct {
+	// Foreground is the default glyph color. Nil means black.
+	Foreground color.Color
+	// Background fills the image behind the glyphs. Nil means transparent.
+	Background color.Color
+	// LaTeXMacros enables a preamble mapping common LaTeX math commands to
+	// plain TeX: \frac, \dfrac, \tfrac, \binom, \text, \textbf, \textit,
+	// \mathrm, \mathbf, \mathit, \mathcal, \mathbb, \operatorname, \color,
+	// \textcolor and the aligned, cases, matrix, pmatrix and bmatrix
+	// environments.
+	LaTeXMacros bool
+}
+
+// SetTeXConfig sets the configuration used for rendering TeX snippets.
+//
+// Regardless of the configuration, colors can be changed within a snippet
+// using the DVI color specials, for example
+//
+//	$\special{color push rgb 1 0 0} x \special{color pop} + y$
+//
+// Colors are specified by the models rgb, RGB, gray, Gray, cmyk, HTML, as
+// "#rrggbb" or by a basic color name like "red" or "blue".
+func SetTeXConfig(c TeXConfig) {
+	texConfig = c
+}
+
+// key returns the part of the TeX cache key determined by 'c'.
+func (c *TeXConfig) key() string {
+	return fmt.Sprintf("%s/%s/%v", texColorKey(c.Foreground), texColorKey(c.Background), c.LaTeXMacros)
+}
+
+func texColorKey(c color.Color) string {
+	if c == nil {
+		return "-"
+	}
+
+	r, g, b, a := c.RGBA()
+	return fmt.Sprintf("%04x%04x%04x%04x", r, g, b, a)
+}
+
+func (c *TeXConfig) preamble() string {
+	if c.LaTeXMacros {
+		return texLaTeXMacros
+	}
+
+	return ""
+}
+
+func (c *TeXConfig) foreground() color.Color {
+	if c.Foreground == nil {
+		return color.Black
+	}
+
+	return c.Foreground
+}
+
+func (c *TeXConfig) background() color.Color {
+	if c.Background == nil {
+		return color.Transparent
+	}
+
+	return c.Background
+}
+
+// texColorHandler handles DVI color specials. It replaces dvi.ColorHandler,
+// which uses a fixed black default color and panics on unknown color names.
+type texColorHandler struct {
+	stack []color.Color
+	bkg   color.Color
+}
+
+var _ dvi.Handler = (*texColorHandler)(nil)
+
+func newTeXColorHandler(c *TeXConfig) *texColorHandler {
+	return &texColorHandler{stack: []color.Color{c.foreground()}, bkg: c.background()}
+}
+
+// Color returns the current glyph color.
+func (h *texColorHandler) Color() color.Color {
+	return h.stack[len(h.stack)-1]
+}
+
+// Background returns the background color.
+func (h *texColorHandler) Background() color.Color {
+	return h.bkg
+}
+
+// Handle implements dvi.Handler.
+func (h *texColorHandler) Handle(p []byte) (err error) {
+	s := string(bytes.TrimSpace(p))
+	switch {
+	case s == "color pop":
+		if len(h.stack) > 1 {
+			h.stack = h.stack[:len(h.stack)-1]
+		}
+	case strings.HasPrefix(s, "color push "):
+		c, err := texParseColor(s[len("color push "):])
+		if err != nil {
+			return err
+		}
+
+		h.stack = append(h.stack, c)
+	case strings.HasPrefix(s, "color "):
+		c, err := texParseColor(s[len("color "):])
+		if err != nil {
+			return err
+		}
+
+		h.stack = append(h.stack[:0], c)
+	case strings.HasPrefix(s, "background "):
+		c, err := texParseColor(s[len("background "):])
+		if err != nil {
+			return err
+		}
+
+		h.bkg = c
+	default:
+		return dvi.ErrSkipHandler
+	}
+	return nil
+}
+
+// texParseColor parses a color special argument.
+func texParseColor(s string) (r color.Color, err error) {
+	s = strings.TrimSpace(s)
+	model, args, _ := strings.Cut(s, " ")
+	f := strings.Fields(args)
+	nums := func(n int, max float64) (r []uint8, err error) {
+		if len(f) != n {
+			return nil, fmt.Errorf("invalid color %q", s)
+		}
+
+		for _, v := range f {
+			x, err := strconv.ParseFloat(v, 64)
+			if err != nil || x < 0 || x > max {
+				return nil, fmt.Errorf("invalid color %q", s)
+			}
+
+			r = append(r, uint8(x/max*255+0.5))
+		}
+		return r, nil
+	}
+	var a []uint8
+	switch model {
+	case "rgb", "RGB":
+		m := 1.0
+		if model == "RGB" {
+			m = 255
+		}
+		if a, err = nums(3, m); err != nil {
+			return nil, err
+		}
+
+		return color.RGBA{a[0], a[1], a[2], 255}, nil
+	case "gray", "Gray":
+		m := 1.0
+		if model == "Gray" {
+			m = 255
+		}
+		if a, err = nums(1, m); err != nil {
+			return nil, err
+		}
+
+		return color.Gray{a[0]}, nil
+	case "cmyk":
+		if a, err = nums(4, 1); err != nil {
+			return nil, err
+		}
+
+		return color.CMYK{a[0], a[1], a[2], a[3]}, nil
+	case "HTML":
+		s = "#" + args
+	}
+	if strings.HasPrefix(s, "#") {
+		h := s[1:]
+		if len(h) == 3 {
+			h = string([]byte{h[0], h[0], h[1], h[1], h[2], h[2]})
+		}
+		if n, err := strconv.ParseUint(h, 16, 32); err == nil && len(h) == 6 {
+			return color.RGBA{uint8(n >> 16), uint8(n >> 8), uint8(n), 255}, nil
+		}
+
+		return nil, fmt.Errorf("invalid color %q", s)
+	}
+
+	if c, ok := texColorNames[strings.ToLower(s)]; ok {
+		return c, nil
+	}
+
+	return nil, fmt.Errorf("unknown color %q", s)
+}
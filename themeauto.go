@@ -0,0 +1,322 @@
+// Copyright 2025 The tk9.0-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tk9_0 // import "modernc.org/tk9.0"
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/adrg/xdg"
+)
+
+const (
+	// themeAutoPollInterval is how often the Tcl thread checks the result of
+	// the detector goroutine. The check does no I/O.
+	themeAutoPollInterval = time.Second
+	// themeAutoProbeInterval is how often the detector goroutine probes the
+	// preference when the portal cannot be monitored for changes.
+	themeAutoProbeInterval = 30 * time.Second
+	// portalReadTimeout limits reading the preference from the portal. The
+	// first read blocks the Tcl thread and gdbus waits about 25 seconds for
+	// an unresponsive portal.
+	portalReadTimeout = 2 * time.Second
+)
+
+// Values of the org.freedesktop.appearance color-scheme setting.
+const (
+	colorSchemeDefault = iota
+	colorSchemeDark
+	colorSchemeLight
+)
+
+// Values of themeAuto.want.
+const (
+	themeAutoUnknown = iota
+	themeAutoLight
+	themeAutoDark
+)
+
+var (
+	portalArgs = []string{"--session", "--dest", "org.freedesktop.portal.Desktop", "--object-path", "/org/freedesktop/portal/desktop"}
+	portalRe   = regexp.MustCompile(`uint32 (\d+)`)
+
+	themeAuto struct {
+		bound     bool               // The <Destroy> binding of App is set.
+		cancel    context.CancelFunc // Kills the portal monitor, if any.
+		dark      bool               // Variant currently in use.
+		darkNm    string             //
+		lightNm   string             //
+		stop      chan struct{}      // Closed to stop the detector goroutine, nil when not following.
+		switching bool               // Set while themeAutoActivate changes the theme.
+		ticker    *Ticker            //
+		want      atomic.Int32       // Variant last detected by the detector goroutine.
+	}
+)
+
+// ActivateThemeAuto activates the theme named 'darkName' if the desktop
+// prefers a dark color scheme and the theme named 'lightName' otherwise. The
+// names are looked up as in [ActivateTheme]. After activation, the preference
+// is watched and the theme is switched when the preference changes while the
+// event loop is running.
+//
+// On Linux and other freedesktop.org systems the preference is read from the
+// org.freedesktop.appearance color-scheme setting of the XDG desktop portal,
+// using the gdbus utility. If the portal is not available, the GTK
+// settings.ini files in the user config directory and the GTK_THEME
+// environment variable are consulted. On other systems the light theme is
+// used. The preference is watched by a separate goroutine, without the portal
+// it is probed every 30 seconds.
+//
+// Following the preference stops when [StopThemeAuto] is called, when another
+// theme is activated by other means, like [ActivateTheme] or [StyleThemeUse],
+// and when the App is destroyed.
+//
+// Only the main package can activate a theme.
+func ActivateThemeAuto(lightName, darkName string) (err error) {
+	if !isCalledFromMain() {
+		return NotActivated
+	}
+
+	StopThemeAuto()
+	themeAuto.lightNm, themeAuto.darkNm = lightName, darkName
+	dark, _ := systemDarkMode()
+	if err = themeAutoActivate(dark); err != nil {
+		return err
+	}
+
+	themeAuto.want.Store(themeAutoVariant(dark))
+	if themeAuto.ticker, err = NewTicker(themeAutoPollInterval, themeAutoPoll); err != nil {
+		return err
+	}
+
+	var ctx context.Context
+	ctx, themeAuto.cancel = context.WithCancel(context.Background())
+	themeAuto.stop = make(chan struct{})
+	go themeAutoDetect(ctx, themeAuto.stop)
+	if !themeAuto.bound {
+		themeAuto.bound = true
+		h := newEventHandler("", StopThemeAuto)
+		evalErr(fmt.Sprintf(`bind . <Destroy> {+if {"%%W" eq "."} {eventDispatcher %v}}`, h.id))
+	}
+	return nil
+}
+
+// StopThemeAuto stops following the desktop color scheme preference started
+// by [ActivateThemeAuto]. The current theme remains active.
+func StopThemeAuto() {
+	if themeAuto.stop == nil {
+		return
+	}
+
+	close(themeAuto.stop)
+	themeAuto.stop = nil
+	themeAuto.cancel()
+	themeAuto.ticker.Stop()
+	themeAuto.ticker = nil
+}
+
+func themeAutoVariant(dark bool) int32 {
+	if dark {
+		return themeAutoDark
+	}
+
+	return themeAutoLight
+}
+
+func themeAutoActivate(dark bool) error {
+	nm := themeAuto.lightNm
+	if dark {
+		nm = themeAuto.darkNm
+	}
+	themeAuto.switching = true
+
+	defer func() { themeAuto.switching = false }()
+
+	if err := activateTheme(nm); err != nil && err != AlreadyActivated {
+		return err
+	}
+
+	themeAuto.dark = dark
+	return nil
+}
+
+// themeAutoPoll runs on the Tcl thread and switches the theme when the
+// detector goroutine found a different preference.
+func themeAutoPoll() {
+	if want := themeAuto.want.Load(); want != themeAutoUnknown {
+		if dark := want == themeAutoDark; dark != themeAuto.dark {
+			themeAutoActivate(dark)
+		}
+	}
+}
+
+// themeAutoDetect runs in its own goroutine and records the desktop
+// preference in themeAuto.want until 'stop' is closed.
+func themeAutoDetect(ctx context.Context, stop chan struct{}) {
+	changed := make(chan struct{}, 1)
+	var probe <-chan time.Time
+	if !startColorSchemeMonitor(ctx, changed) {
+		t := time.NewTicker(themeAutoProbeInterval)
+
+		defer t.Stop()
+
+		probe = t.C
+	}
+	for {
+		select {
+		case <-stop:
+			return
+		case <-changed:
+		case <-probe:
+		}
+		dark, _ := systemDarkMode()
+		themeAuto.want.Store(themeAutoVariant(dark))
+	}
+}
+
+// systemDarkMode reports whether the desktop prefers a dark color scheme.
+// The 'ok' result is false if the preference could not be determined.
+func systemDarkMode() (dark, ok bool) {
+	switch goos {
+	case "darwin", "windows":
+		return false, false
+	}
+
+	gdbus, _ := exec.LookPath("gdbus")
+	return detectDarkMode(gdbus, xdg.ConfigHome, os.Getenv("GTK_THEME"))
+}
+
+// detectDarkMode implements systemDarkMode. 'gdbus' is the path of the gdbus
+// utility, if available, 'configHome' is the XDG config directory.
+func detectDarkMode(gdbus, configHome, gtkTheme string) (dark, ok bool) {
+	if gdbus != "" {
+		if scheme, ok := parseColorScheme(portalRead(gdbus)); ok && scheme != colorSchemeDefault {
+			return scheme == colorSchemeDark, true
+		}
+	}
+
+	if gtkTheme != "" {
+		return isDarkThemeName(gtkTheme), true
+	}
+
+	for _, v := range []string{"gtk-4.0", "gtk-3.0"} {
+		if dark, ok = gtkSettingsDarkMode(filepath.Join(configHome, v, "settings.ini")); ok {
+			return dark, true
+		}
+	}
+	return false, false
+}
+
+// parseColorScheme extracts the color-scheme value from gdbus output.
+func parseColorScheme(s string) (scheme int, ok bool) {
+	m := portalRe.FindStringSubmatch(s)
+	if m == nil {
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(m[1])
+	return n, err == nil
+}
+
+func isDarkThemeName(s string) bool {
+	return strings.Contains(strings.ToLower(s), "dark")
+}
+
+// gtkSettingsDarkMode reads the dark mode preference from a GTK settings.ini
+// file.
+func gtkSettingsDarkMode(fn string) (dark, ok bool) {
+	b, err := os.ReadFile(fn)
+	if err != nil {
+		return false, false
+	}
+
+	for _, line := range strings.Split(string(b), "\n") {
+		k, v, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+
+		switch k, v = strings.TrimSpace(k), strings.TrimSpace(v); k {
+		case "gtk-application-prefer-dark-theme":
+			if v == "1" || v == "true" {
+				return true, true
+			}
+
+			ok = true
+		case "gtk-theme-name":
+			if isDarkThemeName(v) {
+				return true, true
+			}
+
+			ok = true
+		}
+	}
+	return false, ok
+}
+
+// startColorSchemeMonitor watches the portal for color-scheme changes in a
+// separate goroutine, which signals them on 'changed'. It reports whether the
+// monitor started. Canceling 'ctx' kills the monitor.
+func startColorSchemeMonitor(ctx context.Context, changed chan struct{}) bool {
+	switch goos {
+	case "darwin", "windows":
+		return false
+	}
+
+	gdbus, err := exec.LookPath("gdbus")
+	if err != nil {
+		return false
+	}
+
+	if _, ok := parseColorScheme(portalRead(gdbus)); !ok {
+		return false
+	}
+
+	cmd := exec.CommandContext(ctx, gdbus, append([]string{"monitor"}, portalArgs...)...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return false
+	}
+
+	if err = cmd.Start(); err != nil {
+		return false
+	}
+
+	go func() {
+		defer cmd.Wait()
+
+		sc := bufio.NewScanner(stdout)
+		for sc.Scan() {
+			if line := sc.Text(); strings.Contains(line, "SettingChanged") && strings.Contains(line, "color-scheme") {
+				select {
+				case changed <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return true
+}
+
+// portalRead returns the gdbus output of reading the color-scheme setting,
+// or nothing if the portal does not answer within portalReadTimeout.
+func portalRead(gdbus string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), portalReadTimeout)
+	defer cancel()
+
+	args := append([]string{"call"}, portalArgs...)
+	args = append(args, "--method", "org.freedesktop.portal.Settings.Read", "org.freedesktop.appearance", "color-scheme")
+	b, _ := exec.CommandContext(ctx, gdbus, args...).Output()
+	return string(b)
+}
@@ -25,6 +25,8 @@ var (
 	currentTheme    Theme
 	currentThemeKey ThemeKey
 
+	themeChangedHandlers []func(old, new ThemeKey)
+
 	_ Theme        = (*theme)(nil)
 	_ Theme        = (*builtinTheme)(nil)
 	_ ThemeContext = themeContext{}
@@ -55,15 +57,144 @@ type builtinTheme struct {
 }
 
 func (t *builtinTheme) Activate(context ThemeContext) error {
-	StyleThemeUse(t.name)
+	styleThemeUse(t.name)
 	return nil
 }
 
 func (t *builtinTheme) Deactivate(context ThemeContext) error {
-	StyleThemeUse("default")
+	styleThemeUse("default")
+	return nil
+}
+
+// builtinThemeKey returns the key of the registered built-in theme 'name'.
+func builtinThemeKey(name string) (r ThemeKey, ok bool) {
+	for k, v := range Themes {
+		if t, ok := v.(*theme); ok {
+			if b, ok := t.inner.(*builtinTheme); ok && b.name == name {
+				return k, true
+			}
+		}
+	}
+	return r, false
+}
+
+// themeUse deactivates the current registered theme, if any, puts the ttk
+// theme 'name', not registered in Themes, in use and updates the register.
+func themeUse(name string) (r string, err error) {
+	old := currentThemeKey
+	if currentTheme != nil {
+		err = currentTheme.Deactivate(nil)
+	}
+	r = styleThemeUse(name)
+	currentTheme = nil
+	currentThemeKey = ThemeKey{Name: name}
+	notifyThemeChanged(old, currentThemeKey)
+	return r, err
+}
+
+// OnThemeChanged registers 'handler' to be called after a different theme
+// is activated using [ActivateTheme], [ActivateThemeVariant] or
+// [StyleThemeUse]. The handler receives the keys of the previous and the new
+// theme. A ttk theme not registered in [Themes] is reported with an empty
+// Type field.
+//
+// Ttk widgets refresh themselves when the theme changes, handlers are
+// useful for updating other theme dependent state, like colors of canvas
+// items or images.
+func OnThemeChanged(handler func(old, new ThemeKey)) {
+	if handler != nil {
+		themeChangedHandlers = append(themeChangedHandlers, handler)
+	}
+}
+
+func notifyThemeChanged(old, new ThemeKey) {
+	if !themeAuto.switching {
+		// The theme was activated explicitly.
+		StopThemeAuto()
+	}
+
+	if old == new {
+		return
+	}
+
+	for _, h := range themeChangedHandlers {
+		h(old, new)
+	}
+}
+
+// ThemeVariant describes registered themes that come in light and dark
+// variants.
+type ThemeVariant struct {
+	// Family is shared by all variants of a theme, like "Azure".
+	Family string
+	// Dark is set for the dark variant.
+	Dark bool
+}
+
+// SetThemeVariant records variant metadata of the registered theme 'k'.
+func SetThemeVariant(k ThemeKey, v ThemeVariant) error {
+	t, ok := Themes[k].(*theme)
+	if !ok {
+		return NotFound
+	}
+
+	t.variant = &v
 	return nil
 }
 
+// ThemeVariantOf returns the variant metadata of the registered theme 'k', if
+// any.
+func ThemeVariantOf(k ThemeKey) (r ThemeVariant, ok bool) {
+	if t, ok := Themes[k].(*theme); ok && t.variant != nil {
+		return *t.variant, true
+	}
+
+	return r, false
+}
+
+// IsDarkTheme reports whether the current theme is registered as a dark
+// variant.
+func IsDarkTheme() bool {
+	v, ok := ThemeVariantOf(currentThemeKey)
+	return ok && v.Dark
+}
+
+// ActivateThemeVariant activates the dark or light variant of the current
+// theme family. It returns [NotFound] if the current theme has no variant
+// metadata or its family has no such variant. Activating the current variant
+// does nothing.
+//
+// Only the main package can activate a theme.
+func ActivateThemeVariant(dark bool) (err error) {
+	if !isCalledFromMain() {
+		return NotActivated
+	}
+
+	cur, ok := ThemeVariantOf(currentThemeKey)
+	if !ok {
+		return NotFound
+	}
+
+	if cur.Dark == dark {
+		return nil
+	}
+
+	var keys []ThemeKey
+	for k := range Themes {
+		if v, ok := ThemeVariantOf(k); ok && v.Family == cur.Family && v.Dark == dark {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return NotFound
+	}
+
+	sort.Slice(keys, func(a, b int) bool {
+		return keys[a].Type < keys[b].Type || keys[a].Type == keys[b].Type && keys[a].Name < keys[b].Name
+	})
+	return Themes[keys[0]].Activate(nil)
+}
+
 func (t *builtinTheme) Finalize(context ThemeContext) error {
 	return nil
 }
@@ -94,6 +225,10 @@ func ActivateTheme(name string) (err error) {
 		return NotActivated
 	}
 
+	return activateTheme(name)
+}
+
+func activateTheme(name string) (err error) {
 	var keys []ThemeKey
 	for k := range Themes {
 		keys = append(keys, k)
@@ -180,8 +315,9 @@ type Theme interface {
 }
 
 type theme struct {
-	inner Theme
-	k     ThemeKey
+	inner   Theme
+	k       ThemeKey
+	variant *ThemeVariant
 
 	activated   bool
 	finalized   bool
@@ -189,6 +325,7 @@ type theme struct {
 }
 
 func (t *theme) Activate(context ThemeContext) (err error) {
+	old := currentThemeKey
 	if currentTheme != nil {
 		currentTheme.Deactivate(nil)
 		currentTheme = nil
@@ -202,6 +339,7 @@ func (t *theme) Activate(context ThemeContext) (err error) {
 			t.activated = true
 			currentTheme = t
 			currentThemeKey = t.k
+			notifyThemeChanged(old, t.k)
 		}
 	}()
 
@@ -217,6 +355,8 @@ func (t *theme) Activate(context ThemeContext) (err error) {
 		if err = t.inner.Initialize(context); err != nil {
 			return err
 		}
+
+		t.initialized = true
 	}
 
 	evalFunc = eval
@@ -50,8 +50,12 @@ var (
 )
 
 func init() {
-	RegisterTheme("Azure light", newTheme("set_theme light"))
-	RegisterTheme("Azure dark", newTheme("set_theme dark"))
+	if k, err := RegisterTheme("Azure light", newTheme("set_theme light")); err == nil {
+		SetThemeVariant(k, ThemeVariant{Family: "Azure"})
+	}
+	if k, err := RegisterTheme("Azure dark", newTheme("set_theme dark")); err == nil {
+		SetThemeVariant(k, ThemeVariant{Family: "Azure", Dark: true})
+	}
 }
 
 func setup(context ThemeContext) (err error) {
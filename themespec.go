@@ -0,0 +1,424 @@
+// Copyright 2025 The tk9.0-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tk9_0 // import "modernc.org/tk9.0"
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+var _ Theme = (*specTheme)(nil)
+
+// ThemeSpec declares a ttk theme in Go, in JSON or in TOML, see
+// [LoadThemeSpec] and [LoadThemeSpecTOML]. A
+// theme spec is registered using [RegisterThemeSpec] and activated like any
+// other registered theme, for example by [ActivateTheme].
+//
+// Values in Styles may refer to Palette colors and Fonts by name using a "$"
+// prefix, for example "$accent". Example:
+//
+//	RegisterThemeSpec(&ThemeSpec{
+//		Name:    "ocean",
+//		Palette: map[string]string{"bg": "#e8f1f8", "accent": "#0b5394"},
+//		Fonts:   map[string]ThemeFont{"ui": {Family: "Helvetica", Size: 10}},
+//		Styles: map[string]StyleSpec{
+//			".": {Configure: map[string]string{"background": "$bg", "font": "$ui"}},
+//			"TButton": {
+//				Configure: map[string]string{"foreground": "$accent", "padding": "8 4"},
+//				Map:       map[string][]StateValue{"foreground": {{"disabled", "gray"}}},
+//			},
+//		},
+//	})
+type ThemeSpec struct {
+	// Name is the ttk theme name.
+	Name string `json:"name"`
+	// Title is the name used in [Themes]. Defaults to Name.
+	Title string `json:"title,omitempty"`
+	// Parent is the ttk theme the new theme inherits from. Defaults to
+	// "clam".
+	Parent string `json:"parent,omitempty"`
+	// Variant, if not nil, is passed to [SetThemeVariant].
+	Variant *ThemeVariant `json:"variant,omitempty"`
+	// Palette maps names to colors.
+	Palette map[string]string `json:"palette,omitempty"`
+	// Fonts maps names to fonts.
+	Fonts map[string]ThemeFont `json:"fonts,omitempty"`
+	// Elements maps element names, like "Accent.TButton.border", to image
+	// elements.
+	Elements map[string]ElementSpec `json:"elements,omitempty"`
+	// Styles maps style names, like "TButton" or ".", to their settings.
+	Styles map[string]StyleSpec `json:"styles,omitempty"`
+}
+
+// ThemeFont describes a font of a [ThemeSpec].
+type ThemeFont struct {
+	Family string `json:"family"`
+	Size   int    `json:"size,omitempty"`
+	Weight string `json:"weight,omitempty"` // "normal" or "bold".
+	Slant  string `json:"slant,omitempty"`  // "roman" or "italic".
+}
+
+// StyleSpec describes a style of a [ThemeSpec].
+type StyleSpec struct {
+	// Configure maps option names, like "background", to values. See
+	// [StyleConfigure].
+	Configure map[string]string `json:"configure,omitempty"`
+	// Map maps option names to state specific values. See [StyleMap].
+	Map map[string][]StateValue `json:"map,omitempty"`
+	// Layout, if not empty, is the Tcl layout specification of the style.
+	// See [StyleLayout].
+	Layout string `json:"layout,omitempty"`
+}
+
+// StateValue is a value used in the widget states matching State, for example
+// "disabled" or "pressed !disabled".
+type StateValue struct {
+	State string `json:"state"`
+	Value string `json:"value"`
+}
+
+// ElementSpec describes an image element of a [ThemeSpec]. See
+// [StyleElementCreate].
+type ElementSpec struct {
+	// Image is the default image of the element.
+	Image ImageSpec `json:"image"`
+	// States lists images used in particular widget states, the first match
+	// wins.
+	States []StateImage `json:"states,omitempty"`
+	// Border, Padding, Sticky, Width and Height are the options of the
+	// element, see the ttk_image(n) manual page.
+	Border  string `json:"border,omitempty"`
+	Padding string `json:"padding,omitempty"`
+	Sticky  string `json:"sticky,omitempty"`
+	Width   int    `json:"width,omitempty"`
+	Height  int    `json:"height,omitempty"`
+}
+
+// StateImage is an image used in the widget states matching State.
+type StateImage struct {
+	State string `json:"state"`
+	ImageSpec
+}
+
+// ImageSpec provides image data. Exactly one of the fields should be set.
+type ImageSpec struct {
+	// File is the name of an image file in a format supported by [NewPhoto].
+	File string `json:"file,omitempty"`
+	// SVG is the source of an SVG image.
+	SVG string `json:"svg,omitempty"`
+	// Image is a Go image. It cannot be loaded from JSON or TOML.
+	Image image.Image `json:"-"`
+}
+
+// LoadThemeSpec decodes a JSON encoded [ThemeSpec] from 'r'. The JSON field
+// names are the lower cased field names of ThemeSpec and related types.
+func LoadThemeSpec(r io.Reader) (spec *ThemeSpec, err error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	spec = &ThemeSpec{}
+	if err = dec.Decode(spec); err != nil {
+		return nil, fmt.Errorf("decoding theme spec: %v", err)
+	}
+
+	return spec, spec.check()
+}
+
+// LoadThemeSpecTOML decodes a TOML encoded [ThemeSpec] from 'r'. The keys are
+// the same as in [LoadThemeSpec]. Style and element names containing dots
+// must be quoted, for example
+//
+//	name = "ocean"
+//
+//	[palette]
+//	bg = "#102030"
+//
+//	[styles.".".configure]
+//	background = "$bg"
+//
+//	[[styles.TButton.map.foreground]]
+//	state = "disabled"
+//	value = "gray"
+//
+// Dates and times are not supported.
+func LoadThemeSpecTOML(r io.Reader) (spec *ThemeSpec, err error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := parseTOML(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("decoding theme spec: %v", err)
+	}
+
+	if b, err = json.Marshal(m); err != nil {
+		return nil, fmt.Errorf("decoding theme spec: %v", err)
+	}
+
+	return LoadThemeSpec(bytes.NewReader(b))
+}
+
+// LoadThemeSpecFile is like [LoadThemeSpec] but reads the file 'fn'. Files
+// with the .toml extension are decoded using [LoadThemeSpecTOML]. Relative
+// image file names in the spec are resolved against the directory of 'fn'.
+func LoadThemeSpecFile(fn string) (spec *ThemeSpec, err error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	load := LoadThemeSpec
+	if strings.EqualFold(filepath.Ext(fn), ".toml") {
+		load = LoadThemeSpecTOML
+	}
+	if spec, err = load(f); err != nil {
+		return nil, fmt.Errorf("%s: %v", fn, err)
+	}
+
+	dir := filepath.Dir(fn)
+	for k, v := range spec.Elements {
+		v.Image.File = resolveFile(dir, v.Image.File)
+		for i := range v.States {
+			v.States[i].File = resolveFile(dir, v.States[i].File)
+		}
+		spec.Elements[k] = v
+	}
+	return spec, nil
+}
+
+func resolveFile(dir, fn string) string {
+	if fn == "" || filepath.IsAbs(fn) {
+		return fn
+	}
+
+	return filepath.Join(dir, fn)
+}
+
+// RegisterThemeSpec registers the theme described by 'spec'. The theme is
+// created in Tk when first activated.
+func RegisterThemeSpec(spec *ThemeSpec) (r ThemeKey, err error) {
+	if err = spec.check(); err != nil {
+		return r, err
+	}
+
+	title := spec.Title
+	if title == "" {
+		title = spec.Name
+	}
+	if r, err = RegisterTheme(title, &specTheme{spec: spec}); err != nil {
+		return r, err
+	}
+
+	if spec.Variant != nil {
+		err = SetThemeVariant(r, *spec.Variant)
+	}
+	return r, err
+}
+
+// check reports errors in 's' detectable without Tk.
+func (s *ThemeSpec) check() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return fmt.Errorf("theme spec: missing name")
+	}
+
+	_, err := s.script(nil)
+	return err
+}
+
+// resolve returns the Tcl word for the value 'v'.
+func (s *ThemeSpec) resolve(v string) (string, error) {
+	if !strings.HasPrefix(v, "$") {
+		return tclSafeString(v), nil
+	}
+
+	nm := v[1:]
+	if c, ok := s.Palette[nm]; ok {
+		return tclSafeString(c), nil
+	}
+
+	if f, ok := s.Fonts[nm]; ok {
+		a := []string{tclSafeString(f.Family)}
+		if f.Size != 0 {
+			a = append(a, fmt.Sprint(f.Size))
+		}
+		for _, v := range []string{f.Weight, f.Slant} {
+			if v != "" {
+				a = append(a, tclSafeString(v))
+			}
+		}
+		return fmt.Sprintf("[list %s]", strings.Join(a, " ")), nil
+	}
+
+	return "", fmt.Errorf("theme spec %s: undefined name %q", s.Name, v)
+}
+
+func sortedKeys[T any](m map[string]T) (r []string) {
+	for k := range m {
+		r = append(r, k)
+	}
+	slices.Sort(r)
+	return r
+}
+
+// script returns the Tcl script creating the theme. 'images' maps element
+// names to the names of their default and state images, in order. A nil
+// 'images' is used only for checking the spec.
+func (s *ThemeSpec) script(images map[string][]string) (r string, err error) {
+	var b strings.Builder
+	parent := s.Parent
+	if parent == "" {
+		parent = "clam"
+	}
+	fmt.Fprintf(&b, "ttk::style theme create %s -parent %s -settings {\n", tclSafeString(s.Name), tclSafeString(parent))
+	for _, nm := range sortedKeys(s.Elements) {
+		el := s.Elements[nm]
+		imgs := images[nm]
+		if images != nil && len(imgs) != len(el.States)+1 {
+			return "", fmt.Errorf("theme spec %s: element %s: missing images", s.Name, nm)
+		}
+
+		a := []string{"img"}
+		if images != nil {
+			a = []string{imgs[0]}
+		}
+		for i, v := range el.States {
+			img := "img"
+			if images != nil {
+				img = imgs[i+1]
+			}
+			a = append(a, tclSafeString(v.State), img)
+		}
+		fmt.Fprintf(&b, "\tttk::style element create %s image [list %s]", tclSafeString(nm), strings.Join(a, " "))
+		for _, v := range []struct{ opt, val string }{
+			{"border", el.Border},
+			{"padding", el.Padding},
+			{"sticky", el.Sticky},
+		} {
+			if v.val != "" {
+				fmt.Fprintf(&b, " -%s %s", v.opt, tclSafeString(v.val))
+			}
+		}
+		if el.Width != 0 {
+			fmt.Fprintf(&b, " -width %d", el.Width)
+		}
+		if el.Height != 0 {
+			fmt.Fprintf(&b, " -height %d", el.Height)
+		}
+		b.WriteString("\n")
+	}
+	for _, nm := range sortedKeys(s.Styles) {
+		st := s.Styles[nm]
+		if st.Layout != "" {
+			fmt.Fprintf(&b, "\tttk::style layout %s {%s}\n", tclSafeString(nm), st.Layout)
+		}
+		if len(st.Configure) != 0 {
+			fmt.Fprintf(&b, "\tttk::style configure %s", tclSafeString(nm))
+			for _, opt := range sortedKeys(st.Configure) {
+				v, err := s.resolve(st.Configure[opt])
+				if err != nil {
+					return "", err
+				}
+
+				fmt.Fprintf(&b, " -%s %s", tclSafeString(strings.TrimPrefix(opt, "-")), v)
+			}
+			b.WriteString("\n")
+		}
+		if len(st.Map) != 0 {
+			fmt.Fprintf(&b, "\tttk::style map %s", tclSafeString(nm))
+			for _, opt := range sortedKeys(st.Map) {
+				var a []string
+				for _, sv := range st.Map[opt] {
+					v, err := s.resolve(sv.Value)
+					if err != nil {
+						return "", err
+					}
+
+					a = append(a, tclSafeString(sv.State), v)
+				}
+				fmt.Fprintf(&b, " -%s [list %s]", tclSafeString(strings.TrimPrefix(opt, "-")), strings.Join(a, " "))
+			}
+			b.WriteString("\n")
+		}
+	}
+	b.WriteString("}")
+	return b.String(), nil
+}
+
+// specTheme implements Theme for a ThemeSpec.
+type specTheme struct {
+	spec   *ThemeSpec
+	images []*Img
+}
+
+func newSpecImage(s ImageSpec) (*Img, error) {
+	switch {
+	case s.Image != nil:
+		return NewPhoto(Data(s.Image)), nil
+	case s.SVG != "":
+		return NewPhoto(Data(s.SVG)), nil
+	case s.File != "":
+		return NewPhoto(File(s.File)), nil
+	default:
+		return nil, fmt.Errorf("empty image")
+	}
+}
+
+// Initialize implements Theme. It creates the element images and the ttk
+// theme.
+func (t *specTheme) Initialize(context ThemeContext) (err error) {
+	images := map[string][]string{}
+	for _, nm := range sortedKeys(t.spec.Elements) {
+		el := t.spec.Elements[nm]
+		specs := []ImageSpec{el.Image}
+		for _, v := range el.States {
+			specs = append(specs, v.ImageSpec)
+		}
+		for _, v := range specs {
+			img, err := newSpecImage(v)
+			if err != nil {
+				return fmt.Errorf("theme spec %s: element %s: %v", t.spec.Name, nm, err)
+			}
+
+			t.images = append(t.images, img)
+			images[nm] = append(images[nm], img.name)
+		}
+	}
+	script, err := t.spec.script(images)
+	if err != nil {
+		return err
+	}
+
+	_, err = context.Eval(script)
+	return err
+}
+
+// Activate implements Theme.
+func (t *specTheme) Activate(context ThemeContext) error {
+	_, err := context.Eval(fmt.Sprintf("ttk::style theme use %s", tclSafeString(t.spec.Name)))
+	return err
+}
+
+// Deactivate implements Theme.
+func (t *specTheme) Deactivate(context ThemeContext) error {
+	return nil
+}
+
+// Finalize implements Theme. It deletes the element images.
+func (t *specTheme) Finalize(context ThemeContext) error {
+	for _, v := range t.images {
+		v.Delete()
+	}
+	t.images = nil
+	return nil
+}
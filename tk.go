@@ -43,6 +43,8 @@ const (
 	goos           = runtime.GOOS
 	libVersion     = libtk9_0.Version
 
+	vncGeometryPollInterval = 500 * time.Millisecond
+
 	tcl_eval_direct = 0x40000
 	tcl_ok          = 0
 	tcl_error       = 1
@@ -111,6 +113,7 @@ var (
 	initialized        bool
 	isBuilder          = os.Getenv("MODERNC_BUILDER") != ""
 	isVNC              = os.Getenv("TK9_VNC") == "1"
+	vncGeometryWatcher *Ticker
 	testHookWait       = os.Getenv(testHookWaitVar)
 	wmTitle            string
 
@@ -643,6 +646,13 @@ func (e *Event) SetReturnCodeContinue() {
 	e.returnCode = tcl_continue
 }
 
+// Args returns the arguments appended by Tcl to the command invoking the event
+// handler, if any. For example, a scrollbar command receives the scroll
+// position.
+func (e *Event) Args() []string {
+	return e.args
+}
+
 // ScrollSet communicates events to scrollbars. Example:
 //
 //	var scroll *TScrollbarWidget
@@ -1849,6 +1859,9 @@ func (w *Window) Wait() {
 		case os.Getenv("TK9_VNC") == "1":
 			autocenterDisabled = true
 			WmGeometry(App, fmt.Sprintf("%sx%s+0+0", os.Getenv("TK9_VNC_WIDTH"), os.Getenv("TK9_VNC_HEIGHT")))
+			if fn := os.Getenv("TK9_VNC_GEOMETRY_FILE"); fn != "" && vncGeometryWatcher == nil {
+				vncWatchGeometry(fn)
+			}
 		case forcedX >= 0 && forcedY >= 0: // Behind TK9_DEMO=1.
 			evalErr(fmt.Sprintf("wm geometry . +%v+%v", forcedX, forcedY)) //TODO add API func
 			forcedX, forcedY = -1, -1                                      // Apply only the first time.
@@ -1867,6 +1880,42 @@ func (w *Window) Wait() {
 	evalErr(fmt.Sprintf("tkwait window %s", w))
 }
 
+// vncFileDialogOptions adds the VNC session file directory as the default
+// -initialdir of a file dialog.
+func vncFileDialogOptions(options []Opt) []Opt {
+	if dir := os.Getenv("TK9_VNC_FILES_DIR"); dir != "" && collectOne("-initialdir", options...) == "" {
+		return append(options[:len(options):len(options)], Initialdir(dir))
+	}
+
+	return options
+}
+
+// vncWatchGeometry watches the screen size published by the VNC server in
+// file 'fn' and resizes App to match it when the browser window is resized.
+// The file is read by a goroutine, the ticker only applies the new geometry.
+func vncWatchGeometry(fn string) {
+	var pending atomic.Value // string
+	go func() {
+		last := fmt.Sprintf("%sx%s", os.Getenv("TK9_VNC_WIDTH"), os.Getenv("TK9_VNC_HEIGHT"))
+		for range time.Tick(vncGeometryPollInterval) {
+			if b, err := os.ReadFile(fn); err == nil {
+				if g := strings.TrimSpace(string(b)); g != "" && g != last {
+					last = g
+					pending.Store(g)
+				}
+			}
+		}
+	}()
+	var err error
+	if vncGeometryWatcher, err = NewTicker(vncGeometryPollInterval, func() {
+		if g, _ := pending.Swap("").(string); g != "" {
+			WmGeometry(App, g+"+0+0")
+		}
+	}); err != nil {
+		fail(err)
+	}
+}
+
 // WaitVisibility — Wait for a window to change visibility
 //
 // # Description
@@ -4267,11 +4316,43 @@ func (w *TextWidget) Delete(options ...any) {
 //
 //	t.TagConfigure("pre", Font(CourierFont(), 10)
 //
+// The <a href="..."> tag inserts a link. Clicking it calls the handler set by
+// [TextWidget.SetLinkHandler] with the href value.
+//
+// The <ul>, <ol> and <li> tags insert bulleted and numbered lists, which can be
+// nested. The <table> tag, with <tr>, <th> and <td> rows and cells, inserts the
+// table as an embedded grid of labels.
+//
+// The tags <h1> to <h6>, <b>, <strong>, <i>, <em>, <u>, <del>, <code> and <a>
+// are configured with default styles derived from the widget font, unless
+// already configured in 'w'. The <h1> to <h6> headings start on a new line.
+//
+// The style attribute of any element accepts the CSS properties color,
+// background-color, font-weight, font-style and text-decoration, for example
+//
+//	<span style="color: red; font-weight: bold">
+//
 // Other ML-tags are used as names of configured 'w' tags, if configured,
 // ignored otherwise.
 //
 // Example usage in _examples/embed.go.
 func (w *TextWidget) InsertML(list ...any) {
+	w.InsertMLAt("end", list...)
+}
+
+// Text — Create and manipulate 'text' hypertext editing widgets
+//
+// # Description
+//
+// InsertMLAt is like [TextWidget.InsertML] but inserts the content at 'index'
+// instead of at the end of 'w'. It returns the indices of the start and the
+// end of the inserted content, which can be used for example to tag, or later
+// delete or replace, the range:
+//
+//	start, end := t.InsertMLAt("insert", "<b>Hello</b> world")
+//	...
+//	t.Delete(start, end)
+func (w *TextWidget) InsertMLAt(index string, list ...any) (start, end string) {
 	var ml bytes.Buffer
 	for i := 0; i < len(list); i++ {
 		switch x := list[i].(type) {
@@ -4314,78 +4395,13 @@ func (w *TextWidget) InsertML(list ...any) {
 	doc, err := html.Parse(&ml)
 	if err != nil {
 		fail(err)
-		return
+		return "", ""
 	}
 
-	var tags []string
-	var body int
-	k := TkScaling() * 72 / 600
-	walk(0, doc, func(lvl int, n *html.Node) bool {
-		switch n.Type {
-		case html.TextNode:
-			if lvl < len(tags) {
-				tags = tags[:lvl]
-			}
-			tags := tags[body+1:]
-			for _, v := range tags {
-				if v == "pre" {
-					evalErr(fmt.Sprintf("%s insert end %s %s", w, tclSafeString(unescapeML(n.Data)), tclSafeStrings(tags...)))
-					return true
-				}
-			}
-
-			ids, toks := tokenize(n.Data)
-			for i, id := range ids {
-				switch id {
-				default:
-					if s := toks[i]; strings.HasPrefix(s, "$$") && strings.HasSuffix(s, "$$") || strings.HasPrefix(s, "$") && strings.HasSuffix(s, "$") {
-						img := NewPhoto(Data(TeX(s, k)))
-						evalErr(fmt.Sprintf("%v image create end -image %s -align top", w, img))
-						break
-					}
-
-					fallthrough
-				case 0:
-					evalErr(fmt.Sprintf("%s insert end %s {%s}", w, tclFromElementNode(unescapeML(toks[i])), tclSafeStrings(tags...)))
-				}
-			}
-		case html.ElementNode:
-			switch n.Data {
-			case "br":
-				evalErr(fmt.Sprintf("%s insert end \\n {%s}", w, tclSafeStrings(tags...)))
-			case "body":
-				tags = append(tags, n.Data)
-				body = lvl
-			case "img":
-				var src string
-				var opts []string
-				for _, v := range n.Attr {
-					switch v.Key {
-					case "src":
-						src = v.Val
-					case "opt":
-						opts = append(opts, v.Val)
-					}
-				}
-				evalErr(fmt.Sprintf("%v image create end -image %s %s", w, src, strings.Join(opts, " ")))
-			case "embed":
-				var src string
-				var opts []string
-				for _, v := range n.Attr {
-					switch v.Key {
-					case "src":
-						src = v.Val
-					case "opt":
-						opts = append(opts, v.Val)
-					}
-				}
-				evalErr(fmt.Sprintf("%v window create end -window %s %s", w, src, strings.Join(opts, " ")))
-			default:
-				tags = append(tags, n.Data)
-			}
-		}
-		return true
-	})
+	r := &mlRenderer{textRenderer: newTextRenderer(w, index), k: TkScaling() * 72 / 600}
+	defer r.close()
+	r.node(doc, nil)
+	return r.finish()
 }
 
 func unescapeML(s string) string {
@@ -4444,14 +4460,6 @@ func Align(val any) Opt {
 	return rawOption(fmt.Sprintf(`-align %s`, optionString(val)))
 }
 
-func walk(lvl int, n *html.Node, visitor func(lvl int, n *html.Node) (dive bool)) {
-	for ; n != nil; n = n.NextSibling {
-		if visitor(lvl, n) {
-			walk(lvl+1, n.FirstChild, visitor)
-		}
-	}
-}
-
 // Fontchooser — control font selection dialog
 //
 // # Description
@@ -4603,11 +4611,15 @@ func FontchooserHide() {
 // Specifies a string to display as the title of the dialog box. If this option
 // is not specified, then a default title is displayed.
 //
+// When the app runs in a VNC session, the dialog opens by default in the
+// session directory holding the files uploaded from the web browser, see
+// package [modernc.org/tk9.0/vnc].
+//
 // Additional information might be available at the [Tcl/Tk getopenfile] page.
 //
 // [Tcl/Tk getopenfile]: https://www.tcl.tk/man/tcl9.0/TkCmd/getOpenFile.html
 func GetOpenFile(options ...Opt) (r []string) {
-	return parseList(evalErr(fmt.Sprintf("tk_getOpenFile %s", collect(options...))))
+	return parseList(evalErr(fmt.Sprintf("tk_getOpenFile %s", collect(vncFileDialogOptions(options)...))))
 }
 
 // FileType specifies a single file type for the [Filetypes] option.
@@ -4679,11 +4691,15 @@ type FileType struct {
 // Specifies a string to display as the title of the dialog box. If this option
 // is not specified, then a default title is displayed.
 //
+// When the app runs in a VNC session, the dialog opens by default in the
+// session directory holding the files uploaded from the web browser, see
+// package [modernc.org/tk9.0/vnc].
+//
 // Additional information might be available at the [Tcl/Tk getopenfile] page.
 //
 // [Tcl/Tk getopenfile]: https://www.tcl.tk/man/tcl9.0/TkCmd/getOpenFile.html
 func GetSaveFile(options ...Opt) string {
-	return evalErr(fmt.Sprintf("tk_getSaveFile %s", collect(options...)))
+	return evalErr(fmt.Sprintf("tk_getSaveFile %s", collect(vncFileDialogOptions(options)...)))
 }
 
 // Place — Geometry manager for fixed or rubber-sheet placement
@@ -5681,17 +5697,35 @@ func StyleThemeStyles(themeName ...string) []string {
 // Additional information might be available at the [Tcl/Tk style] page.
 // There's also a [Styles and Themes] tutorial at tkdoc.com.
 //
-// This mechanism is separate from the RegisterTheme/ActivateTheme one.  Use of
-// this function is best suited for the built in themes like "clam" etc.
+// Setting a theme goes through the same register as [ActivateTheme]: the
+// built-in themes, like "clam", are activated as their registered [Themes]
+// entries, other ttk themes deactivate the current registered theme. In both
+// cases the [OnThemeChanged] handlers are called.
 //
 // [Tcl/Tk style]: https://www.tcl.tk/man/tcl9.0/TkCmd/ttk_style.html
 // [Styles and Themes]: https://tkdocs.com/tutorial/styles.html
 func StyleThemeUse(themeName ...string) string {
-	var s string
-	if len(themeName) != 0 {
-		s = tclSafeString(themeName[0])
+	if len(themeName) == 0 {
+		return evalErr("ttk::style theme use")
 	}
-	return evalErr(fmt.Sprintf("ttk::style theme use %s", s))
+
+	name := themeName[0]
+	if k, ok := builtinThemeKey(name); ok {
+		if err := Themes[k].Activate(nil); err != nil {
+			fail(err)
+		}
+		return ""
+	}
+
+	r, err := themeUse(name)
+	if err != nil {
+		fail(err)
+	}
+	return r
+}
+
+func styleThemeUse(name string) string {
+	return evalErr(fmt.Sprintf("ttk::style theme use %s", tclSafeString(name)))
 }
 
 // CourierFont returns "{courier new}" on Windows and "courier" elsewhere.
@@ -6648,23 +6682,36 @@ func (w *TNotebookWidget) Tabs() (r []*Window) {
 
 type Ticker struct {
 	eh *eventHandler
+	nm string
 }
 
 func NewTicker(d time.Duration, handler func()) (r *Ticker, err error) {
 	eh := newEventHandler("", handler)
 	nm := fmt.Sprintf("ticker%v", id.Add(1))
 	if _, err = eval(fmt.Sprintf(`proc %s {} {
-	after %v {
+	set ::%[1]s [after %v {
 		eventDispatcher %v
-		%[1]s
-	}
+		if {[info exists ::%[1]s]} %[1]s
+	}]
 }
 %[1]s
 `, nm, d.Milliseconds(), eh.id)); err != nil {
 		return nil, err
 	}
 
-	return &Ticker{eh: eh}, nil
+	return &Ticker{eh: eh, nm: nm}, nil
+}
+
+// Stop stops the ticker, the handler will not be called again. Stop may be
+// called from the handler.
+func (t *Ticker) Stop() {
+	if t == nil || t.nm == "" {
+		return
+	}
+
+	evalErr(fmt.Sprintf("after cancel $::%[1]s; unset ::%[1]s; rename %[1]s {}", t.nm))
+	delete(handlers, t.eh.id)
+	t.nm = ""
 }
 
 // ttk::checkbutton — On/off widget
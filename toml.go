@@ -0,0 +1,421 @@
+// Copyright 2025 The tk9.0-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tk9_0 // import "modernc.org/tk9.0"
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// tomlParser decodes the subset of TOML v1.0.0 used by theme specs: tables,
+// arrays of tables, dotted and quoted keys, all string forms, integers,
+// floats, booleans, arrays and inline tables. Dates and times are not
+// supported.
+type tomlParser struct {
+	src string
+	pos int
+}
+
+// parseTOML decodes 'src' into nested map[string]any and []any values.
+func parseTOML(src string) (r map[string]any, err error) {
+	p := &tomlParser{src: src}
+	r = map[string]any{}
+	cur := r
+	for {
+		p.skipSpace(true)
+		if p.eof() {
+			return r, nil
+		}
+
+		switch {
+		case strings.HasPrefix(p.src[p.pos:], "[["):
+			p.pos += 2
+			keys, err := p.key()
+			if err != nil {
+				return nil, err
+			}
+
+			if !p.skip("]]") {
+				return nil, p.errorf("expected ]]")
+			}
+
+			t, err := p.table(r, keys[:len(keys)-1])
+			if err != nil {
+				return nil, err
+			}
+
+			k := keys[len(keys)-1]
+			a, ok := t[k].([]any)
+			if t[k] != nil && !ok {
+				return nil, p.errorf("%s is not an array of tables", k)
+			}
+
+			cur = map[string]any{}
+			t[k] = append(a, cur)
+		case p.src[p.pos] == '[':
+			p.pos++
+			keys, err := p.key()
+			if err != nil {
+				return nil, err
+			}
+
+			if !p.skip("]") {
+				return nil, p.errorf("expected ]")
+			}
+
+			if cur, err = p.table(r, keys); err != nil {
+				return nil, err
+			}
+		default:
+			if err := p.keyValue(cur); err != nil {
+				return nil, err
+			}
+		}
+		p.skipSpace(false)
+		if !p.eof() && p.src[p.pos] != '\n' && !strings.HasPrefix(p.src[p.pos:], "\r\n") {
+			return nil, p.errorf("expected end of line")
+		}
+	}
+}
+
+func (p *tomlParser) eof() bool { return p.pos >= len(p.src) }
+
+func (p *tomlParser) errorf(s string, args ...any) error {
+	return fmt.Errorf("toml: line %d: %s", strings.Count(p.src[:min(p.pos, len(p.src))], "\n")+1, fmt.Sprintf(s, args...))
+}
+
+// skip consumes 's' if it is next in the input.
+func (p *tomlParser) skip(s string) bool {
+	if strings.HasPrefix(p.src[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+
+	return false
+}
+
+// skipSpace skips white space and comments. New lines are skipped only if
+// 'newlines' is true.
+func (p *tomlParser) skipSpace(newlines bool) {
+	for !p.eof() {
+		switch c := p.src[p.pos]; {
+		case c == ' ' || c == '\t' || c == '\r':
+			p.pos++
+		case c == '\n' && newlines:
+			p.pos++
+		case c == '#':
+			for !p.eof() && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// table returns the table at 'keys' below 't', creating it if necessary. The
+// last element of an array of tables is used.
+func (p *tomlParser) table(t map[string]any, keys []string) (map[string]any, error) {
+	for _, k := range keys {
+		switch x := t[k].(type) {
+		case nil:
+			m := map[string]any{}
+			t[k] = m
+			t = m
+		case map[string]any:
+			t = x
+		case []any:
+			var m map[string]any
+			if len(x) != 0 {
+				m, _ = x[len(x)-1].(map[string]any)
+			}
+			if m == nil {
+				return nil, p.errorf("%s is not a table", k)
+			}
+
+			t = m
+		default:
+			return nil, p.errorf("%s is not a table", k)
+		}
+	}
+	return t, nil
+}
+
+// key parses a possibly dotted key.
+func (p *tomlParser) key() (r []string, err error) {
+	for {
+		p.skipSpace(false)
+		if p.eof() {
+			return nil, p.errorf("expected key")
+		}
+
+		var k string
+		switch c := p.src[p.pos]; c {
+		case '"', '\'':
+			if k, err = p.str(); err != nil {
+				return nil, err
+			}
+		default:
+			start := p.pos
+			for !p.eof() && isTOMLBareKeyChar(p.src[p.pos]) {
+				p.pos++
+			}
+			if p.pos == start {
+				return nil, p.errorf("expected key")
+			}
+
+			k = p.src[start:p.pos]
+		}
+		r = append(r, k)
+		p.skipSpace(false)
+		if !p.skip(".") {
+			return r, nil
+		}
+	}
+}
+
+func isTOMLBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// keyValue parses a key/value pair and stores it in 't'.
+func (p *tomlParser) keyValue(t map[string]any) error {
+	keys, err := p.key()
+	if err != nil {
+		return err
+	}
+
+	if !p.skip("=") {
+		return p.errorf("expected =")
+	}
+
+	p.skipSpace(false)
+	v, err := p.value()
+	if err != nil {
+		return err
+	}
+
+	if t, err = p.table(t, keys[:len(keys)-1]); err != nil {
+		return err
+	}
+
+	k := keys[len(keys)-1]
+	if _, ok := t[k]; ok {
+		return p.errorf("duplicate key %s", k)
+	}
+
+	t[k] = v
+	return nil
+}
+
+func (p *tomlParser) value() (any, error) {
+	if p.eof() {
+		return nil, p.errorf("expected value")
+	}
+
+	switch c := p.src[p.pos]; c {
+	case '"', '\'':
+		return p.str()
+	case '[':
+		p.pos++
+		r := []any{}
+		for {
+			p.skipSpace(true)
+			if p.skip("]") {
+				return r, nil
+			}
+
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+
+			r = append(r, v)
+			p.skipSpace(true)
+			if p.skip("]") {
+				return r, nil
+			}
+
+			if !p.skip(",") {
+				return nil, p.errorf("expected , or ]")
+			}
+		}
+	case '{':
+		p.pos++
+		r := map[string]any{}
+		p.skipSpace(false)
+		if p.skip("}") {
+			return r, nil
+		}
+
+		for {
+			if err := p.keyValue(r); err != nil {
+				return nil, err
+			}
+
+			p.skipSpace(false)
+			if p.skip("}") {
+				return r, nil
+			}
+
+			if !p.skip(",") {
+				return nil, p.errorf("expected , or }")
+			}
+		}
+	}
+
+	start := p.pos
+	for !p.eof() && strings.IndexByte(" \t\r\n,]}#", p.src[p.pos]) < 0 {
+		p.pos++
+	}
+	s := p.src[start:p.pos]
+	switch s {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "inf", "+inf", "-inf", "nan", "+nan", "-nan":
+		return nil, p.errorf("unsupported value %s", s)
+	}
+
+	n := strings.ReplaceAll(s, "_", "")
+	if v, err := strconv.ParseInt(n, 0, 64); err == nil {
+		return v, nil
+	}
+
+	if v, err := strconv.ParseFloat(n, 64); err == nil && !strings.ContainsAny(n, "xXoObB") {
+		return v, nil
+	}
+
+	return nil, p.errorf("invalid value %q", s)
+}
+
+// str parses any of the four string forms.
+func (p *tomlParser) str() (string, error) {
+	var b strings.Builder
+	switch {
+	case p.skip(`"""`):
+		p.skip("\r")
+		p.skip("\n")
+		for {
+			switch {
+			case p.eof():
+				return "", p.errorf("unterminated string")
+			case p.skip(`"""`):
+				// Up to two additional quotes belong to the string.
+				for i := 0; i < 2 && p.skip(`"`); i++ {
+					b.WriteByte('"')
+				}
+				return b.String(), nil
+			case strings.HasPrefix(p.src[p.pos:], "\\\n") || strings.HasPrefix(p.src[p.pos:], "\\\r\n"):
+				// Line ending backslash.
+				p.pos++
+				p.skipBlank()
+			case p.src[p.pos] == '\\':
+				if err := p.escape(&b); err != nil {
+					return "", err
+				}
+			default:
+				b.WriteByte(p.src[p.pos])
+				p.pos++
+			}
+		}
+	case p.skip(`'''`):
+		p.skip("\r")
+		p.skip("\n")
+		end := strings.Index(p.src[p.pos:], `'''`)
+		if end < 0 {
+			return "", p.errorf("unterminated string")
+		}
+
+		// Up to two additional quotes belong to the string.
+		for i := 0; i < 2 && p.pos+end+3 < len(p.src) && p.src[p.pos+end+3] == '\''; i++ {
+			end++
+		}
+		s := p.src[p.pos : p.pos+end]
+		p.pos += end + 3
+		return s, nil
+	case p.skip(`"`):
+		for {
+			switch {
+			case p.eof() || p.src[p.pos] == '\n':
+				return "", p.errorf("unterminated string")
+			case p.skip(`"`):
+				return b.String(), nil
+			case p.src[p.pos] == '\\':
+				if err := p.escape(&b); err != nil {
+					return "", err
+				}
+			default:
+				b.WriteByte(p.src[p.pos])
+				p.pos++
+			}
+		}
+	case p.skip(`'`):
+		end := strings.IndexAny(p.src[p.pos:], "'\n")
+		if end < 0 || p.src[p.pos+end] != '\'' {
+			return "", p.errorf("unterminated string")
+		}
+
+		s := p.src[p.pos : p.pos+end]
+		p.pos += end + 1
+		return s, nil
+	default:
+		return "", p.errorf("expected string")
+	}
+}
+
+// skipBlank skips white space including new lines, but not comments.
+func (p *tomlParser) skipBlank() {
+	for !p.eof() && strings.IndexByte(" \t\r\n", p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// escape decodes the escape sequence at the current position.
+func (p *tomlParser) escape(b *strings.Builder) error {
+	p.pos++ // '\\'
+	if p.eof() {
+		return p.errorf("invalid escape")
+	}
+
+	c := p.src[p.pos]
+	p.pos++
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case '"', '\\':
+		b.WriteByte(c)
+	case 'u', 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+		if p.pos+n > len(p.src) {
+			return p.errorf("invalid escape")
+		}
+
+		r, err := strconv.ParseUint(p.src[p.pos:p.pos+n], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(r)) {
+			return p.errorf("invalid escape")
+		}
+
+		p.pos += n
+		b.WriteRune(rune(r))
+	default:
+		return p.errorf("invalid escape \\%c", c)
+	}
+	return nil
+}
@@ -0,0 +1,461 @@
+// Copyright 2025 The tk9.0-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tk9_0 // import "modernc.org/tk9.0"
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// undoManagers maps text widget paths to their undo managers.
+var undoManagers = map[string]*UndoManager{}
+
+// EditKind is the kind of an [Edit].
+type EditKind int
+
+const (
+	EditInsert EditKind = iota
+	EditDelete
+)
+
+// Edit is a single insertion or deletion recorded by an [UndoManager].
+type Edit struct {
+	Kind EditKind `json:"kind"`
+	// Index is the "line.char" position where Text was inserted or
+	// deleted.
+	Index string `json:"index"`
+	Text  string `json:"text"`
+}
+
+// UndoGroup is a sequence of edits undone and redone as a whole.
+type UndoGroup []Edit
+
+// UndoManager records the edits of a TextWidget in an undo history kept in
+// Go.
+//
+// UndoManager wraps the text widget in a [TextWidgetProxy] and intercepts its
+// 'insert', 'delete', 'replace' and 'edit' operations, so edits made by the
+// user and by code are recorded alike and the standard <<Undo>> and <<Redo>>
+// bindings use the Go history. The Tk undo mechanism of the widget is disabled.
+//
+// Consecutive single character insertions, or deletions, on the same line are
+// merged into one undo group until [UndoManager.Separator] is called or an
+// edit breaks the run.
+type UndoManager struct {
+	TextWidgetProxy
+
+	depth    int       // BeginGroup nesting
+	group    UndoGroup // edits of the open explicit group
+	maxDepth int
+	merging  bool // the last undo group is an open typing run
+	onChange []func()
+	redo     []UndoGroup
+	undo     []UndoGroup
+}
+
+// NewUndoManager returns an UndoManager recording the edits of 'w'.
+func NewUndoManager(w *TextWidget) (r *UndoManager) {
+	r = &UndoManager{TextWidgetProxy: NewTextWidgetProxy(w)}
+	r.EvalWrapped([]string{"configure", "-undo", "0"})
+	r.Register("insert", r.insert)
+	r.Register("delete", r.delete)
+	r.Register("replace", r.replace)
+	r.Register("edit", r.edit)
+	undoManagers[w.String()] = r
+	return r
+}
+
+// Close stops recording and removes the wrapping of the text widget. The Tk
+// undo mechanism remains disabled.
+func (m *UndoManager) Close() {
+	delete(undoManagers, m.TextWidget.String())
+	m.TextWidgetProxy.Close()
+}
+
+// SetMaxDepth limits the number of undo groups kept, the oldest are dropped
+// first. Zero means no limit, which is the default.
+func (m *UndoManager) SetMaxDepth(n int) {
+	m.maxDepth = n
+	m.trim()
+}
+
+// OnChange registers 'handler' to be called after every change of the
+// history, for example to enable or disable toolbar buttons according to
+// [UndoManager.CanUndo] and [UndoManager.CanRedo].
+func (m *UndoManager) OnChange(handler func()) {
+	m.onChange = append(m.onChange, handler)
+}
+
+// CanUndo reports whether there is something to undo.
+func (m *UndoManager) CanUndo() bool {
+	return len(m.undo) != 0 || len(m.group) != 0
+}
+
+// CanRedo reports whether there is something to redo.
+func (m *UndoManager) CanRedo() bool {
+	return len(m.redo) != 0
+}
+
+// UndoStack returns a copy of the undo history, oldest group first.
+func (m *UndoManager) UndoStack() []UndoGroup {
+	return copyGroups(m.undo)
+}
+
+// RedoStack returns a copy of the redo history, the group redone first is
+// last.
+func (m *UndoManager) RedoStack() []UndoGroup {
+	return copyGroups(m.redo)
+}
+
+func copyGroups(s []UndoGroup) (r []UndoGroup) {
+	for _, v := range s {
+		r = append(r, append(UndoGroup(nil), v...))
+	}
+	return r
+}
+
+// BeginGroup starts an undo group. All edits until the matching
+// [UndoManager.EndGroup] are undone as a whole. Groups can be nested, only the
+// outermost group is recorded.
+func (m *UndoManager) BeginGroup() {
+	if m.depth == 0 {
+		m.merging = false
+	}
+	m.depth++
+}
+
+// EndGroup ends an undo group started by [UndoManager.BeginGroup].
+func (m *UndoManager) EndGroup() {
+	if m.depth == 0 {
+		return
+	}
+
+	if m.depth--; m.depth != 0 || len(m.group) == 0 {
+		return
+	}
+
+	g := m.group
+	m.group = nil
+	m.push(g)
+}
+
+// Separator ends the current run of merged typing, the next edit starts a new
+// undo group.
+func (m *UndoManager) Separator() {
+	m.merging = false
+}
+
+// Reset clears the history.
+func (m *UndoManager) Reset() {
+	m.undo, m.redo, m.group, m.depth, m.merging = nil, nil, nil, 0, false
+	m.changed()
+}
+
+// Undo reverts the last undo group. Any open group is ended first.
+func (m *UndoManager) Undo() {
+	m.closeGroups()
+	if len(m.undo) == 0 {
+		return
+	}
+
+	g := m.undo[len(m.undo)-1]
+	m.undo = m.undo[:len(m.undo)-1]
+	for i := len(g) - 1; i >= 0; i-- {
+		m.apply(g[i], true)
+	}
+	m.redo = append(m.redo, g)
+	m.changed()
+}
+
+// Redo reapplies the last undone group.
+func (m *UndoManager) Redo() {
+	m.closeGroups()
+	if len(m.redo) == 0 {
+		return
+	}
+
+	g := m.redo[len(m.redo)-1]
+	m.redo = m.redo[:len(m.redo)-1]
+	for _, v := range g {
+		m.apply(v, false)
+	}
+	m.undo = append(m.undo, g)
+	m.changed()
+}
+
+func (m *UndoManager) closeGroups() {
+	m.merging = false
+	if m.depth != 0 {
+		m.depth = 1
+		m.EndGroup()
+	}
+}
+
+// apply performs 'e', or its inverse, on the wrapped widget without recording
+// it and moves the insertion cursor to the edit.
+func (m *UndoManager) apply(e Edit, inverse bool) {
+	end := fmt.Sprintf("%s + %d chars", e.Index, utf8.RuneCountInString(e.Text))
+	if (e.Kind == EditInsert) != inverse {
+		m.EvalWrapped([]string{"insert", e.Index, e.Text})
+		m.EvalWrapped([]string{"mark", "set", "insert", end})
+	} else {
+		m.EvalWrapped([]string{"delete", e.Index, end})
+		m.EvalWrapped([]string{"mark", "set", "insert", e.Index})
+	}
+	m.EvalWrapped([]string{"see", "insert"})
+}
+
+// record adds 'e' to the history.
+func (m *UndoManager) record(e Edit) {
+	if m.depth != 0 {
+		m.group = append(m.group, e)
+		return
+	}
+
+	if m.merging && len(m.undo) != 0 {
+		g := m.undo[len(m.undo)-1]
+		if m.merge(&g[len(g)-1], e) {
+			m.redo = nil
+			m.changed()
+			return
+		}
+	}
+
+	m.push(UndoGroup{e})
+	m.merging = isTyping(e)
+}
+
+// merge merges 'e' into the typing run 'prev' if 'e' continues it.
+func (m *UndoManager) merge(prev *Edit, e Edit) bool {
+	if !isTyping(e) || prev.Kind != e.Kind {
+		return false
+	}
+
+	switch e.Kind {
+	case EditInsert:
+		if e.Index == editEnd(*prev) {
+			prev.Text += e.Text
+			return true
+		}
+	case EditDelete:
+		switch {
+		case e.Index == prev.Index: // Delete key
+			prev.Text += e.Text
+			return true
+		case editEnd(e) == prev.Index: // BackSpace key
+			prev.Index = e.Index
+			prev.Text = e.Text + prev.Text
+			return true
+		}
+	}
+	return false
+}
+
+// isTyping reports whether 'e' can be part of a merged typing run.
+func isTyping(e Edit) bool {
+	return utf8.RuneCountInString(e.Text) == 1 && e.Text != "\n"
+}
+
+// editEnd returns the index following the text of 'e', which must not contain
+// a newline.
+func editEnd(e Edit) string {
+	line, char := parseIndex(e.Index)
+	return fmt.Sprintf("%d.%d", line, char+utf8.RuneCountInString(e.Text))
+}
+
+func parseIndex(s string) (line, char int) {
+	fmt.Sscanf(s, "%d.%d", &line, &char)
+	return line, char
+}
+
+func (m *UndoManager) push(g UndoGroup) {
+	m.undo = append(m.undo, g)
+	m.redo = nil
+	m.trim()
+	m.changed()
+}
+
+func (m *UndoManager) trim() {
+	if m.maxDepth > 0 && len(m.undo) > m.maxDepth {
+		m.undo = append([]UndoGroup(nil), m.undo[len(m.undo)-m.maxDepth:]...)
+	}
+}
+
+func (m *UndoManager) changed() {
+	for _, v := range m.onChange {
+		v()
+	}
+}
+
+// index returns the "line.char" form of 'index' clamped to the last character
+// that can be edited, ie. before the final newline.
+func (m *UndoManager) index(index string) string {
+	if r, _ := m.EvalWrapped([]string{"compare", index, ">=", "end"}); r == "1" {
+		index = "end - 1c"
+	}
+	r, _ := m.EvalWrapped([]string{"index", index})
+	return r
+}
+
+func (m *UndoManager) disabled() bool {
+	r, _ := m.EvalWrapped([]string{"cget", "-state"})
+	return r == "disabled"
+}
+
+func (m *UndoManager) insert(args []string) (string, error) {
+	if len(args) < 3 || m.disabled() {
+		return m.EvalWrapped(args)
+	}
+
+	e := Edit{Kind: EditInsert, Index: m.index(args[1])}
+	for i := 2; i < len(args); i += 2 {
+		e.Text += args[i]
+	}
+	r, err := m.EvalWrapped(args)
+	if err == nil && e.Text != "" {
+		m.record(e)
+	}
+	return r, err
+}
+
+func (m *UndoManager) delete(args []string) (string, error) {
+	if len(args) < 2 || m.disabled() {
+		return m.EvalWrapped(args)
+	}
+
+	type span struct{ from, to string }
+	var spans []span
+	for i := 1; i < len(args); i += 2 {
+		from := m.index(args[i])
+		to := from + " + 1c"
+		if i+1 < len(args) {
+			to = args[i+1]
+		}
+		if to = m.index(to); indexLess(from, to) {
+			spans = append(spans, span{from, to})
+		}
+	}
+	if len(spans) == 1 && len(args) <= 3 {
+		text, _ := m.EvalWrapped([]string{"get", spans[0].from, spans[0].to})
+		r, err := m.EvalWrapped(args)
+		if err == nil {
+			m.record(Edit{Kind: EditDelete, Index: spans[0].from, Text: text})
+		}
+		return r, err
+	}
+
+	// Delete multiple ranges from the last one, so the indices of the
+	// others remain valid.
+	sort.Slice(spans, func(i, j int) bool { return indexLess(spans[j].from, spans[i].from) })
+	m.BeginGroup()
+	defer m.EndGroup()
+	for _, v := range spans {
+		text, _ := m.EvalWrapped([]string{"get", v.from, v.to})
+		if _, err := m.EvalWrapped([]string{"delete", v.from, v.to}); err != nil {
+			return "", err
+		}
+
+		m.record(Edit{Kind: EditDelete, Index: v.from, Text: text})
+	}
+	return "", nil
+}
+
+// replace records the replacement as a deletion followed by an insertion in
+// one undo group.
+func (m *UndoManager) replace(args []string) (string, error) {
+	if len(args) < 4 || m.disabled() {
+		return m.EvalWrapped(args)
+	}
+
+	from, to := m.index(args[1]), m.index(args[2])
+	var text string
+	if indexLess(from, to) {
+		text, _ = m.EvalWrapped([]string{"get", from, to})
+	}
+	e := Edit{Kind: EditInsert, Index: from}
+	for i := 3; i < len(args); i += 2 {
+		e.Text += args[i]
+	}
+	r, err := m.EvalWrapped(args)
+	if err != nil {
+		return r, err
+	}
+
+	m.BeginGroup()
+	defer m.EndGroup()
+	if text != "" {
+		m.record(Edit{Kind: EditDelete, Index: from, Text: text})
+	}
+	if e.Text != "" {
+		m.record(e)
+	}
+	return r, nil
+}
+
+// indexLess reports whether the "line.char" index 'a' is before 'b'.
+func indexLess(a, b string) bool {
+	la, ca := parseIndex(a)
+	lb, cb := parseIndex(b)
+	return la < lb || la == lb && ca < cb
+}
+
+func (m *UndoManager) edit(args []string) (string, error) {
+	if len(args) > 1 {
+		switch args[1] {
+		case "undo":
+			m.Undo()
+			return "", nil
+		case "redo":
+			m.Redo()
+			return "", nil
+		case "canundo":
+			return fmt.Sprint(boolToInt(m.CanUndo())), nil
+		case "canredo":
+			return fmt.Sprint(boolToInt(m.CanRedo())), nil
+		case "separator":
+			m.Separator()
+		case "reset":
+			m.Reset()
+		}
+	}
+	return m.EvalWrapped(args)
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+type undoHistory struct {
+	Undo []UndoGroup `json:"undo"`
+	Redo []UndoGroup `json:"redo"`
+}
+
+// MarshalJSON implements json.Marshaler. It serializes the history, for
+// example for crash recovery. The history is only meaningful together with the
+// text content it was recorded for.
+func (m *UndoManager) MarshalJSON() ([]byte, error) {
+	m.closeGroups()
+	return json.Marshal(undoHistory{m.undo, m.redo})
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It replaces the history by one
+// produced by [UndoManager.MarshalJSON].
+func (m *UndoManager) UnmarshalJSON(b []byte) error {
+	var h undoHistory
+	if err := json.Unmarshal(b, &h); err != nil {
+		return err
+	}
+
+	m.undo, m.redo, m.group, m.depth, m.merging = h.Undo, h.Redo, nil, 0, false
+	m.trim()
+	m.changed()
+	return nil
+}
@@ -0,0 +1,414 @@
+// Copyright 2025 The tk9.0-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tk9_0 // import "modernc.org/tk9.0"
+
+import (
+	"fmt"
+	"math"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ValidationAction is the kind of edit being validated.
+type ValidationAction int
+
+const (
+	ValidationDelete ValidationAction = 0  // Text is deleted.
+	ValidationInsert ValidationAction = 1  // Text is inserted.
+	ValidationOther  ValidationAction = -1 // Forced, focus or textvariable validation.
+)
+
+// ValidationEvent describes a validation of an [Entry], [TEntry], [Spinbox]
+// or [TSpinbox] value, see [Validate].
+type ValidationEvent struct {
+	W *Window
+	// Action is the kind of the edit.
+	Action ValidationAction
+	// Index is the character index of the inserted or deleted text, or -1.
+	Index int
+	// Proposed is the value the widget will have if the edit is allowed.
+	Proposed string
+	// Prior is the value before the edit.
+	Prior string
+	// Text is the inserted or deleted text, if any.
+	Text string
+	// Mode is the current value of the -validate option.
+	Mode string
+	// Reason is the event that triggered the validation: "key",
+	// "focusin", "focusout" or "forced".
+	Reason string
+}
+
+// Partial reports whether the value is still being typed, ie. whether the
+// validation was triggered by a key press. Validators can then accept
+// incomplete values, like "-" for an integer.
+func (v ValidationEvent) Partial() bool {
+	return v.Reason == "key"
+}
+
+// Validator reports whether a value is valid.
+type Validator func(v ValidationEvent) bool
+
+type validateOpt struct {
+	h    *eventHandler
+	mode string
+}
+
+func (o validateOpt) optionString(w *Window) string {
+	o.h.w = w
+	return fmt.Sprintf("-validate %s -validatecommand {eventDispatcher %v %%d %%i %%P %%s %%S %%v %%V}", o.mode, o.h.id)
+}
+
+// Validate option.
+//
+// If 'val' is a Validator, or a func(ValidationEvent) bool, the option sets
+// the -validate mode to "all" and the -validatecommand to a command invoking
+// the validator with the substituted values. Use [ValidateOn] to select another
+// mode. Ttk widgets get the "invalid" [Window.WidgetState] while their value is
+// invalid, it can be styled using [StyleMap], for example
+//
+//	StyleMap("TEntry", Fieldbackground, "invalid", "#ffd0d0")
+//
+// Any other 'val' sets the -validate mode.
+//
+// Known uses:
+//   - [Entry] (widget specific)
+//   - [Spinbox] (widget specific)
+//   - [TEntry] (widget specific)
+//   - [TSpinbox]
+func Validate(val any) Opt {
+	switch x := val.(type) {
+	case Validator:
+		return ValidateOn("all", x)
+	case func(ValidationEvent) bool:
+		return ValidateOn("all", x)
+	default:
+		return rawOption(fmt.Sprintf(`-validate %s`, optionString(val)))
+	}
+}
+
+// Validate — Get the configured option value.
+//
+// Known uses:
+//   - [Entry] (widget specific)
+//   - [Spinbox] (widget specific)
+//   - [TEntry] (widget specific)
+//   - [TSpinbox]
+func (w *Window) Validate() string {
+	return evalErr(fmt.Sprintf(`%s cget -validate`, w))
+}
+
+// ValidateOn is like [Validate] with a Validator, but it sets the -validate
+// mode to 'mode', one of "none", "focus", "focusin", "focusout", "key" or
+// "all".
+func ValidateOn(mode string, validator Validator) Opt {
+	return validateOpt{
+		h: newEventHandler("", func(e *Event) {
+			v := newValidationEvent(e.W, e.args)
+			ok := validator(v)
+			state := "invalid"
+			if ok {
+				state = "!invalid"
+				e.Result = "1"
+			} else {
+				e.Result = "0"
+			}
+			// Classic widgets have no state command.
+			if strings.HasPrefix(evalErr(fmt.Sprintf("winfo class %s", e.W)), "T") {
+				e.W.WidgetState(state)
+			}
+		}),
+		mode: mode,
+	}
+}
+
+func newValidationEvent(w *Window, args []string) (r ValidationEvent) {
+	r.W = w
+	r.Action = ValidationOther
+	r.Index = -1
+	for i, v := range args {
+		switch i {
+		case 0:
+			r.Action = ValidationAction(atoi(v))
+		case 1:
+			if n, err := strconv.Atoi(v); err == nil {
+				r.Index = n
+			}
+		case 2:
+			r.Proposed = v
+		case 3:
+			r.Prior = v
+		case 4:
+			r.Text = v
+		case 5:
+			r.Mode = v
+		case 6:
+			r.Reason = v
+		}
+	}
+	return r
+}
+
+// AllOf returns a Validator accepting values accepted by all of 'validators'.
+func AllOf(validators ...Validator) Validator {
+	return func(v ValidationEvent) bool {
+		for _, f := range validators {
+			if !f(v) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// IntegerRange returns a Validator accepting integers between 'min' and
+// 'max', inclusive. While typing, an empty value is accepted as are values
+// that can still grow into the range by appending digits, for example "-1" for
+// a range of -20..-10.
+func IntegerRange(min, max int) Validator {
+	return func(v ValidationEvent) bool {
+		s := v.Proposed
+		if v.Partial() {
+			return s == "" || integerPrefix(s, min, max)
+		}
+
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return false
+		}
+
+		return n >= min && n <= max
+	}
+}
+
+// integerPrefix reports whether 's', an optional sign followed by digits, can
+// become an integer between 'min' and 'max' by appending zero or more digits.
+func integerPrefix(s string, min, max int) bool {
+	neg := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		neg, s = true, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	var p uint64
+	for _, c := range s {
+		if c < '0' || c > '9' || p > (math.MaxUint64-9)/10 {
+			return false
+		}
+
+		p = 10*p + uint64(c-'0')
+	}
+	// The range of magnitudes of the values in min..max having the sign of
+	// 's'. Zero has both signs.
+	var lo, hi uint64
+	switch {
+	case neg:
+		if min > 0 {
+			return false
+		}
+
+		lo, hi = 0, magnitude(min)
+		if max < 0 {
+			lo = magnitude(max)
+		}
+	default:
+		if max < 0 {
+			return false
+		}
+
+		lo, hi = 0, magnitude(max)
+		if min > 0 {
+			lo = magnitude(min)
+		}
+	}
+	// Appending k digits to 'p' produces the magnitudes p*10^k to
+	// p*10^k+10^k-1. A sign alone needs at least one digit.
+	pow := uint64(1)
+	if s == "" {
+		pow = 10
+	}
+	for ; pow <= math.MaxUint64/10 && p <= hi/pow; pow *= 10 {
+		if p*pow+pow-1 >= lo {
+			return true
+		}
+	}
+	return false
+}
+
+// magnitude returns the absolute value of 'n'.
+func magnitude(n int) uint64 {
+	if n < 0 {
+		return uint64(-(n + 1)) + 1
+	}
+
+	return uint64(n)
+}
+
+var partialFloat = regexp.MustCompile(`^[-+]?([0-9]*\.?[0-9]*([eE][-+]?[0-9]*)?)?$`)
+
+// Float returns a Validator accepting floating point numbers.
+func Float() Validator {
+	return func(v ValidationEvent) bool {
+		if v.Partial() && partialFloat.MatchString(v.Proposed) {
+			return true
+		}
+
+		_, err := strconv.ParseFloat(v.Proposed, 64)
+		return err == nil
+	}
+}
+
+// MatchRegexp returns a Validator accepting values matching 're'. Use anchors
+// to match the whole value.
+func MatchRegexp(re *regexp.Regexp) Validator {
+	return func(v ValidationEvent) bool {
+		return re.MatchString(v.Proposed)
+	}
+}
+
+// MaxLength returns a Validator accepting values of at most 'n' characters.
+func MaxLength(n int) Validator {
+	return func(v ValidationEvent) bool {
+		return utf8.RuneCountInString(v.Proposed) <= n
+	}
+}
+
+// Date returns a Validator accepting dates in the numeric [time.Parse]
+// 'layout', for example "2006-01-02". While typing, digits are accepted where
+// the layout has digits and the other characters must match the layout.
+func Date(layout string) Validator {
+	return func(v ValidationEvent) bool {
+		if v.Partial() {
+			if len(v.Proposed) > len(layout) {
+				return false
+			}
+
+			for i := 0; i < len(v.Proposed); i++ {
+				c, l := v.Proposed[i], layout[i]
+				if isDigit(l) != isDigit(c) || !isDigit(l) && c != l {
+					return false
+				}
+			}
+			return true
+		}
+
+		_, err := time.Parse(layout, v.Proposed)
+		return err == nil
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// IPAddress returns a Validator accepting IPv4 addresses in dotted decimal
+// form.
+func IPAddress() Validator {
+	return func(v ValidationEvent) bool {
+		if !v.Partial() {
+			a, err := netip.ParseAddr(v.Proposed)
+			return err == nil && a.Is4()
+		}
+
+		parts := strings.Split(v.Proposed, ".")
+		if len(parts) > 4 {
+			return false
+		}
+
+		for i, p := range parts {
+			if p == "" {
+				if i == len(parts)-1 {
+					continue
+				}
+
+				return false
+			}
+
+			n, err := strconv.Atoi(p)
+			if err != nil || n > 255 || len(p) > 3 || len(p) > 1 && p[0] == '0' {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// PhoneMask is a [Mask] for US style phone numbers.
+const PhoneMask = "(999) 999-9999"
+
+// Mask returns a Validator for masked input. In 'mask', '9' stands for a
+// digit, 'a' for a letter, '*' for any character and any other character for
+// itself.
+//
+// While typing at the end of the value, the literal characters of the mask
+// are inserted automatically, so the user types only the digits and letters.
+func Mask(mask string) Validator {
+	m := []rune(mask)
+	fits := func(c, slot rune) bool {
+		switch slot {
+		case '9':
+			return unicode.IsDigit(c)
+		case 'a':
+			return unicode.IsLetter(c)
+		case '*':
+			return true
+		default:
+			return c == slot
+		}
+	}
+	literal := func(slot rune) bool { return slot != '9' && slot != 'a' && slot != '*' }
+	valid := func(s []rune) bool {
+		if len(s) > len(m) {
+			return false
+		}
+
+		for i, c := range s {
+			if !fits(c, m[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return func(v ValidationEvent) bool {
+		s := []rune(v.Proposed)
+		if !v.Partial() {
+			return len(s) == len(m) && valid(s) || len(s) == 0
+		}
+
+		if valid(s) {
+			return true
+		}
+
+		// Typing a character at a literal position at the end of the value
+		// inserts the literals before it.
+		prior := []rune(v.Prior)
+		text := []rune(v.Text)
+		if v.Action != ValidationInsert || len(text) != 1 || v.Index != len(prior) || !valid(prior) {
+			return false
+		}
+
+		i := len(prior)
+		for i < len(m) && literal(m[i]) {
+			i++
+		}
+		if i == len(prior) || i == len(m) || !fits(text[0], m[i]) {
+			return false
+		}
+
+		// The value cannot be changed from the validation command, do it
+		// when idle and restore the validation mode, see the entry manual
+		// page.
+		s = append(append(prior, m[len(prior):i]...), text[0])
+		evalErr(fmt.Sprintf("after idle {%[1]s configure -validate none; %[1]s insert end %[2]s; %[1]s icursor end; %[1]s configure -validate %[3]s}",
+			v.W, tclSafeString(string(s[len(prior):])), v.Mode))
+		return false
+	}
+}
@@ -0,0 +1,330 @@
+// Copyright 2025 The tk9.0-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build linux || freebsd
+
+package vnc // import "modernc.org/tk9.0/vnc"
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mileusna/useragent"
+)
+
+// Upper bounds of the session duration histogram buckets, in seconds.
+var durationBuckets = []float64{60, 300, 900, 1800, 3600, 4 * 3600, 24 * 3600}
+
+// metrics are the counters exported by Server.MetricsHandler. Protected by
+// the Server mutex.
+type metrics struct {
+	durationCounts []uint64 // Per durationBuckets item, non cumulative.
+	durationSum    float64
+	ended          uint64
+	spawnFailures  map[string]uint64 // Per program.
+	started        uint64
+}
+
+// spawnFailed accounts for a failure to start 'program'.
+func (s *Server) spawnFailed(program string) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.metrics.spawnFailures == nil {
+		s.metrics.spawnFailures = map[string]uint64{}
+	}
+	s.metrics.spawnFailures[program]++
+}
+
+// sessionStarted accounts for a new session.
+func (s *Server) sessionStarted() {
+	s.Lock()
+	defer s.Unlock()
+
+	s.metrics.started++
+}
+
+// sessionEnded accounts for a session that ran for 'd'.
+func (s *Server) sessionEnded(d time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+
+	m := &s.metrics
+	if m.durationCounts == nil {
+		m.durationCounts = make([]uint64, len(durationBuckets))
+	}
+	m.ended++
+	m.durationSum += d.Seconds()
+	for i, v := range durationBuckets {
+		if d.Seconds() <= v {
+			m.durationCounts[i]++
+			break
+		}
+	}
+}
+
+// MetricsHandler returns a handler serving the server metrics in the
+// Prometheus text exposition format.
+func (s *Server) MetricsHandler() http.Handler {
+	return http.HandlerFunc(s.serveMetrics)
+}
+
+func (s *Server) serveMetrics(w http.ResponseWriter, rq *http.Request) {
+	s.Lock()
+	active := s.sessions
+	m := s.metrics
+	failures := make(map[string]uint64, len(m.spawnFailures))
+	for k, v := range m.spawnFailures {
+		failures[k] = v
+	}
+	counts := slices.Clone(m.durationCounts)
+	s.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	fmt.Fprintf(w, "# HELP tk9_vnc_sessions_active Number of running app instances.\n")
+	fmt.Fprintf(w, "# TYPE tk9_vnc_sessions_active gauge\n")
+	fmt.Fprintf(w, "tk9_vnc_sessions_active %d\n", active)
+	fmt.Fprintf(w, "# HELP tk9_vnc_sessions_started_total Number of started sessions.\n")
+	fmt.Fprintf(w, "# TYPE tk9_vnc_sessions_started_total counter\n")
+	fmt.Fprintf(w, "tk9_vnc_sessions_started_total %d\n", m.started)
+	fmt.Fprintf(w, "# HELP tk9_vnc_spawn_failures_total Number of failures to start a session process.\n")
+	fmt.Fprintf(w, "# TYPE tk9_vnc_spawn_failures_total counter\n")
+	var programs []string
+	for k := range failures {
+		programs = append(programs, k)
+	}
+	slices.Sort(programs)
+	for _, k := range programs {
+		fmt.Fprintf(w, "tk9_vnc_spawn_failures_total{program=%q} %d\n", k, failures[k])
+	}
+	fmt.Fprintf(w, "# HELP tk9_vnc_session_duration_seconds Duration of ended sessions.\n")
+	fmt.Fprintf(w, "# TYPE tk9_vnc_session_duration_seconds histogram\n")
+	var n uint64
+	for i, v := range durationBuckets {
+		if i < len(counts) {
+			n += counts[i]
+		}
+		fmt.Fprintf(w, "tk9_vnc_session_duration_seconds_bucket{le=\"%g\"} %d\n", v, n)
+	}
+	fmt.Fprintf(w, "tk9_vnc_session_duration_seconds_bucket{le=\"+Inf\"} %d\n", m.ended)
+	fmt.Fprintf(w, "tk9_vnc_session_duration_seconds_sum %g\n", m.durationSum)
+	fmt.Fprintf(w, "tk9_vnc_session_duration_seconds_count %d\n", m.ended)
+}
+
+// sessionInfo describes a running session on the admin page.
+type sessionInfo struct {
+	Addr    string
+	Browser string
+	Display string
+	ID      string
+	Procs   []procInfo
+	Started time.Time
+	Token   string // Authorizes the kill action, see Server.verify.
+	User    string
+	Uptime  time.Duration
+}
+
+type procInfo struct {
+	CPU     time.Duration
+	Name    string
+	PID     int
+	RSS     string
+	Running bool
+}
+
+var adminHTML = template.Must(template.New("admin").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}} - sessions</title>
+<style>
+body { font: 14px sans-serif; margin: 1em; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}: {{len .Sessions}} active session(s)</h1>
+<p><a href="{{.Base}}metrics">metrics</a></p>
+<table>
+<tr><th>ID</th><th>User</th><th>Display</th><th>Started</th><th>Client</th><th>Processes</th><th></th></tr>
+{{range .Sessions}}<tr>
+<td>{{.ID}}</td>
+<td>{{.User}}</td>
+<td>{{.Display}}</td>
+<td>{{.Started.Format "2006-01-02 15:04:05"}} ({{.Uptime}})</td>
+<td>{{.Addr}}<br>{{.Browser}}</td>
+<td>{{range .Procs}}{{.Name}} pid {{.PID}}{{if .Running}}: cpu {{.CPU}}, rss {{.RSS}}{{end}}<br>{{end}}</td>
+<td><form method="post" action="{{$.Base}}kill"><input type="hidden" name="token" value="{{.Token}}"><input type="submit" value="Kill"></form></td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// AdminHandler returns a handler serving a page listing the active sessions
+// with an action to terminate them. It also serves MetricsHandler at the
+// "metrics" path below the mount point, for example
+//
+//	http.Handle("/admin/", http.StripPrefix("/admin", s.AdminHandler()))
+//
+// The admin handler does not use Options.Authenticator, access to it must be
+// restricted by the embedding application. The kill action requires a token
+// embedded in the page and rejects cross-origin requests.
+func (s *Server) AdminHandler() http.Handler {
+	return http.HandlerFunc(s.serveAdmin)
+}
+
+func (s *Server) serveAdmin(w http.ResponseWriter, rq *http.Request) {
+	switch p := rq.URL.Path; {
+	case strings.HasSuffix(p, "/metrics"):
+		s.serveMetrics(w, rq)
+	case strings.HasSuffix(p, "/kill"):
+		if rq.Method != "POST" {
+			s.err(w, http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, ok := s.verify("kill", rq.PostFormValue("token"))
+		if !ok || !sameOrigin(rq) {
+			s.err(w, http.StatusForbidden)
+			return
+		}
+
+		c := s.clients.lookup(id)
+		if c == nil {
+			s.err(w, http.StatusNotFound)
+			return
+		}
+
+		if s.opts.Verbose {
+			fmt.Fprintf(os.Stderr, "admin: killing session %s\n", id)
+		}
+		c.disconnect()
+		http.Redirect(w, rq, adminBase(rq), http.StatusSeeOther)
+	default:
+		if err := adminHTML.Execute(w, struct {
+			Base     string
+			Sessions []sessionInfo
+			Title    string
+		}{adminBase(rq), s.sessionInfos(), s.opts.Title}); err != nil {
+			log("%v", err)
+		}
+	}
+}
+
+// adminBase returns the absolute URL path of the admin page, ending in a
+// slash. It uses the path of the request as received by the server, so it does
+// not depend on how the handler is mounted.
+func adminBase(rq *http.Request) string {
+	p := rq.URL.Path
+	if u, err := url.ParseRequestURI(rq.RequestURI); err == nil {
+		p = u.Path
+	}
+	switch {
+	case strings.HasSuffix(p, "/kill"), strings.HasSuffix(p, "/metrics"):
+		return p[:strings.LastIndexByte(p, '/')+1]
+	case !strings.HasSuffix(p, "/"):
+		return p + "/"
+	default:
+		return p
+	}
+}
+
+// sameOrigin reports whether the request, if it carries an Origin header,
+// comes from the host it is addressed to.
+func sameOrigin(rq *http.Request) bool {
+	origin := rq.Header.Get("Origin")
+	if origin == "" {
+		return rq.Header.Get("Sec-Fetch-Site") != "cross-site"
+	}
+
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == rq.Host
+}
+
+// sessionInfos returns the active sessions ordered by start time.
+func (s *Server) sessionInfos() (r []sessionInfo) {
+	for _, c := range s.clients.all() {
+		c.Lock()
+		if c.isConnected {
+			info := sessionInfo{
+				Addr:    c.remoteAddr,
+				Display: c.display,
+				ID:      c.id,
+				Started: c.started,
+				Token:   c.id + "." + s.sign("kill", c.id),
+				User:    c.user,
+				Uptime:  time.Since(c.started).Round(time.Second),
+			}
+			if ua := useragent.Parse(c.userAgent); ua.Name != "" {
+				info.Browser = strings.TrimSpace(fmt.Sprintf("%s %s, %s %s", ua.Name, ua.Version, ua.OS, ua.Device))
+			}
+			for _, v := range []struct {
+				name string
+				cmd  *exec.Cmd
+			}{
+				{"app", c.appCmd},
+				{"Xvfb", c.xvfbCmd},
+				{"x11vnc", c.x11vncCmd},
+				{"x11vnc (spectators)", c.viewCmd},
+			} {
+				if v.cmd != nil && v.cmd.Process != nil {
+					r := procInfo{Name: v.name, PID: v.cmd.Process.Pid}
+					var rss int64
+					if r.CPU, rss, r.Running = procUsage(r.PID); r.Running {
+						r.RSS = fmt.Sprintf("%.1f MiB", float64(rss)/(1<<20))
+					}
+					info.Procs = append(info.Procs, r)
+				}
+			}
+			r = append(r, info)
+		}
+		c.Unlock()
+	}
+	slices.SortFunc(r, func(a, b sessionInfo) int { return a.Started.Compare(b.Started) })
+	return r
+}
+
+// procUsage returns the CPU time and resident set size of process 'pid' as
+// reported by procfs. It reports false if the information is not available.
+func procUsage(pid int) (cpu time.Duration, rss int64, ok bool) {
+	b, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return 0, 0, false
+	}
+
+	// The command name in field 2 may contain spaces, parse after it.
+	s := string(b)
+	x := strings.LastIndexByte(s, ')')
+	if x < 0 {
+		return 0, 0, false
+	}
+
+	f := strings.Fields(s[x+1:])
+	// f[0] is field 3, the process state.
+	if len(f) < 22 {
+		return 0, 0, false
+	}
+
+	const clkTck = 100 // USER_HZ on all supported targets.
+	utime, err1 := strconv.ParseInt(f[11], 10, 64)
+	stime, err2 := strconv.ParseInt(f[12], 10, 64)
+	pages, err3 := strconv.ParseInt(f[21], 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return 0, 0, false
+	}
+
+	cpu = time.Duration(utime+stime) * time.Second / clkTck
+	return cpu, pages * int64(os.Getpagesize()), true
+}
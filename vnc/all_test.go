@@ -5,7 +5,12 @@
 package vnc // import "modernc.org/tk9.0/vnc"
 
 import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -17,3 +22,92 @@ func TestMain(m *testing.M) {
 func Test(t *testing.T) {
 	t.Log("TODO")
 }
+
+func TestBasicAuth(t *testing.T) {
+	a := &BasicAuth{Realm: "test", Check: func(user, password string) bool { return user == "joe" && password == "secret" }}
+	for i, v := range []struct {
+		user, password string
+		ok             bool
+	}{
+		{"joe", "secret", true},
+		{"joe", "wrong", false},
+		{"", "", false},
+	} {
+		rq := httptest.NewRequest("GET", "/", nil)
+		if v.user != "" {
+			rq.SetBasicAuth(v.user, v.password)
+		}
+		user, err := a.Authenticate(rq)
+		if g, e := err == nil, v.ok; g != e {
+			t.Errorf("%v: ok=%v, expected %v", i, g, e)
+			continue
+		}
+
+		if v.ok && user != v.user {
+			t.Errorf("%v: user=%q, expected %q", i, user, v.user)
+		}
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	s, err := NewServer(DefaultOptions())
+	if errors.Is(err, ErrNotSupported) {
+		t.Skip(err)
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	s.MetricsHandler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	b := w.Body.String()
+	for _, v := range []string{
+		"tk9_vnc_sessions_active 0\n",
+		"tk9_vnc_sessions_started_total 0\n",
+		"tk9_vnc_session_duration_seconds_count 0\n",
+	} {
+		if !strings.Contains(b, v) {
+			t.Errorf("missing %q in\n%s", v, b)
+		}
+	}
+}
+
+func TestAdmin(t *testing.T) {
+	s, err := NewServer(DefaultOptions())
+	if errors.Is(err, ErrNotSupported) {
+		t.Skip(err)
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := http.StripPrefix("/admin", s.AdminHandler())
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/admin", nil))
+	if b := w.Body.String(); !strings.Contains(b, `href="/admin/metrics"`) {
+		t.Errorf("metrics link not relative to the mount point:\n%s", b)
+	}
+
+	for i, v := range []struct {
+		token  string
+		origin string
+		code   int
+	}{
+		{"", "", http.StatusForbidden},
+		{"x.y", "", http.StatusForbidden},
+		{"x.y", "http://evil.example", http.StatusForbidden},
+	} {
+		rq := httptest.NewRequest("POST", "/admin/kill", strings.NewReader(url.Values{"token": {v.token}}.Encode()))
+		rq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if v.origin != "" {
+			rq.Header.Set("Origin", v.origin)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, rq)
+		if g, e := w.Code, v.code; g != e {
+			t.Errorf("%v: got status %v, expected %v", i, g, e)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2025 The tk9.0-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build linux || freebsd
+
+package vnc // import "modernc.org/tk9.0/vnc"
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// wsPath is the path prefix, relative to Options.BasePath, of the RFB over
+// websocket endpoints. The full path is wsPath+<client id>.
+const wsPath = "ws/"
+
+// bridge serves the websocket connection of client 'id', proxying RFB
+// traffic to its x11vnc server.
+func (s *Server) bridge(w http.ResponseWriter, rq *http.Request, id, user string) {
+	c := s.clients.lookup(id)
+	if c == nil {
+		s.err(w, http.StatusNotFound)
+		return
+	}
+
+	c.Lock()
+	connected, port, owner, viewPort := c.isConnected, c.port, c.user, c.viewPort
+	c.Unlock()
+	if !connected {
+		s.err(w, http.StatusNotFound)
+		return
+	}
+
+	spectator := false
+	switch sig := rq.URL.Query().Get("view"); {
+	case sig != "":
+		// Spectators connect to the view only x11vnc instance.
+		if _, ok := s.verify("view", id+"."+sig); !ok || !s.opts.Spectators || viewPort == 0 {
+			s.err(w, http.StatusForbidden)
+			return
+		}
+
+		port, spectator = viewPort, true
+	case owner != user || s.cookieSession(rq) != id:
+		s.err(w, http.StatusForbidden)
+		return
+	}
+
+	srv := websocket.Server{
+		Handshake: func(cfg *websocket.Config, rq *http.Request) (err error) {
+			// Reject cross site connections, the page is served by us.
+			if cfg.Origin, err = websocket.Origin(cfg, rq); err != nil {
+				return err
+			}
+
+			if cfg.Origin != nil && cfg.Origin.Host != rq.Host {
+				return fmt.Errorf("origin mismatch: %s", cfg.Origin)
+			}
+
+			// Older noVNC versions require the "binary" subprotocol.
+			if slices.Contains(cfg.Protocol, "binary") {
+				cfg.Protocol = []string{"binary"}
+			}
+			return nil
+		},
+		Handler: func(ws *websocket.Conn) {
+			ws.PayloadType = websocket.BinaryFrame
+			if !spectator {
+				c.Lock()
+				c.viewers++
+				c.Unlock()
+
+				defer func() {
+					c.Lock()
+					c.viewers--
+					c.viewersSeen = time.Now()
+					c.Unlock()
+				}()
+			}
+			if err := proxy(ws, fmt.Sprintf("localhost:%d", port)); err != nil && s.opts.Verbose {
+				fmt.Fprintf(os.Stderr, "websocket bridge for DISPLAY=%s: %v\n", c.display, err)
+			}
+		},
+	}
+	srv.ServeHTTP(w, rq)
+}
+
+// proxy copies data between 'ws' and a TCP connection to 'addr' until either
+// side closes.
+func proxy(ws *websocket.Conn, addr string) error {
+	defer ws.Close()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	errc := make(chan error, 2)
+	go func() {
+		_, err := io.Copy(conn, ws)
+		errc <- err
+	}()
+	go func() {
+		_, err := io.Copy(ws, conn)
+		errc <- err
+	}()
+	return <-errc
+}
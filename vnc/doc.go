@@ -23,8 +23,10 @@
 //
 // # Run time requirements
 //
-// This package needs to be able to execute multiple instances of [Xvfb],
-// [x11vnc] and [websockify].
+// This package needs to be able to execute multiple instances of [Xvfb] and
+// [x11vnc]. If xrandr is available, sessions follow the size of the browser
+// window: the Xvfb screen is resized using the RandR extension and the app
+// resizes its main window to the new screen size.
 //
 // # How to use it
 //
@@ -81,12 +83,81 @@
 //     Produce a lot of additional output on stderr. Can be useful when
 //     debugging connection, permission or other issues. Defaults to false.
 //
+// # Embedding the server
+//
+// The server can be also started programmatically, for example from a
+// separate launcher binary or alongside other HTTP handlers of an existing
+// web service:
+//
+//	opts := vnc.DefaultOptions()
+//	opts.App = "/usr/local/bin/myapp"
+//	opts.BasePath = "/myapp/"
+//	s, err := vnc.NewServer(opts)
+//	if err != nil {
+//		...
+//	}
+//
+//	http.Handle("/myapp/", s.Handler())
+//
+// Alternatively [Server.Start] serves the handler on [Options].Port in the
+// background. [Server.Shutdown] stops the HTTP server and terminates all
+// running app instances. On targets without X11 [NewServer] returns
+// [ErrNotSupported].
+//
+// # Authentication and limits
+//
+// Options.Authenticator authenticates all requests, for example using
+// [BasicAuth] or an [AuthenticatorFunc] checking a session cookie of the
+// embedding web service. The authenticated user identity is passed to the
+// app instance in the TK9_VNC_USER environment variable. Options.MaxSessions
+// and Options.MaxSessionsPerUser cap the number of concurrently running app
+// instances, requests over the limit are answered with status 429.
+// Options.IdleTimeout terminates sessions without user activity.
+//
+// # Reconnecting and spectators
+//
+// Sessions survive closing the browser connection for Options.GracePeriod.
+// Reloading the page or opening the server URL again from the same browser
+// reconnects to the running app instance. The browser is recognized by a
+// signed session cookie.
+//
+// If Options.Spectators is set, every session also gets a read-only page
+// that any number of additional viewers can open, for example for support
+// or demos. Its URL path is passed to the app in the TK9_VNC_SPECTATOR_PATH
+// environment variable.
+//
+// # Clipboard and files
+//
+// The Clipboard panel of the web page shows the text last copied in the app
+// and sends text typed or pasted into it to the app. There is no dedicated
+// bridge, x11vnc synchronizes the X CLIPBOARD selection, which is what
+// [modernc.org/tk9.0.ClipboardAppend] and [modernc.org/tk9.0.ClipboardGet]
+// use, with the VNC cut text exchanged with the page. The panel does not
+// access the system clipboard of the browser, use the usual copy and paste
+// keys in the panel's text area.
+//
+// The Files panel of the web page uploads files to a per session directory
+// and lists the files found there for download. The app gets the directory
+// in the TK9_VNC_FILES_DIR environment variable. The tk9.0 file dialogs open
+// in this directory by default. The directory is removed when the session
+// ends.
+//
+// # Monitoring
+//
+// [Server.AdminHandler] serves a page listing the active sessions, their
+// clients and the resource usage of their processes, with an action to
+// terminate a session. [Server.MetricsHandler] serves session counts, process
+// start failures and session durations in the Prometheus text format. Neither
+// handler is protected by Options.Authenticator.
+//
 // # How it works
 //
 // This package is inspired by [Jeff Smith's] [CloudTk] but does not use any of its code.
 //
-// The VNC server starts a new [Xvfb], [x11vnc] and [websockify] instance per
-// connecting web client. The web client is initially served a small web page
+// The VNC server starts a new [Xvfb] and [x11vnc] instance per connecting web
+// client. The x11vnc server accepts only local connections, the RFB protocol
+// is relayed between the websocket of the browser and x11vnc by the HTTP
+// handler itself. The web client is initially served a small web page
 // that determines the dimensions of the browser window and then redirects the
 // client to a [noVNC] page, connected to a new app instance running on
 // the server using a properly sized X11 virtual frame buffer.
@@ -99,6 +170,5 @@
 // [Xvfb]: https://en.wikipedia.org/wiki/Xvfb
 // [noVNC]: https://github.com/novnc/noVNC
 // [targets supported by tk9.0]: https://pkg.go.dev/modernc.org/tk9.0#hdr-Supported_targets
-// [websockify]: https://github.com/novnc/websockify
 // [x11vnc]: https://en.wikipedia.org/wiki/X11vnc
 package vnc // import "modernc.org/tk9.0/vnc"
@@ -0,0 +1,192 @@
+// Copyright 2025 The tk9.0-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build linux || freebsd
+
+package vnc // import "modernc.org/tk9.0/vnc"
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// EnvVarFilesDir is set to the session directory holding the files
+	// uploaded from the web browser. Files the app stores there can be
+	// downloaded by the browser. The tk9.0 file dialogs open this directory
+	// by default.
+	EnvVarFilesDir = "TK9_VNC_FILES_DIR"
+
+	// filesPath is the path prefix, relative to Options.BasePath, of the file
+	// transfer endpoints. The full paths are filesPath+<client id> for
+	// listing and uploading and filesPath+<client id>/<name> for
+	// downloading.
+	filesPath = "files/"
+)
+
+// fileInfo describes a file of the session directory.
+type fileInfo struct {
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+// serveFiles handles the file transfer requests of the noVNC page.
+func (s *Server) serveFiles(w http.ResponseWriter, rq *http.Request, arg, user string) {
+	id, name, _ := strings.Cut(arg, "/")
+	c := s.clients.lookup(id)
+	if c == nil {
+		s.err(w, http.StatusNotFound)
+		return
+	}
+
+	c.Lock()
+	connected, owner, dir := c.isConnected, c.user, c.filesDir
+	c.Unlock()
+	switch {
+	case !connected || dir == "":
+		s.err(w, http.StatusNotFound)
+		return
+	case owner != user || s.cookieSession(rq) != id:
+		s.err(w, http.StatusForbidden)
+		return
+	}
+
+	switch {
+	case name != "" && rq.Method == "GET":
+		s.download(w, rq, dir, name)
+	case name == "" && rq.Method == "GET":
+		s.listFiles(w, dir)
+	case name == "" && rq.Method == "POST":
+		s.upload(w, rq, dir)
+	default:
+		s.err(w, http.StatusMethodNotAllowed)
+	}
+}
+
+// sessionFile returns the path of file 'name' in 'dir' or an empty string if
+// 'name' is not a plain file name.
+func sessionFile(dir, name string) string {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\\\x00") {
+		return ""
+	}
+
+	return filepath.Join(dir, name)
+}
+
+func (s *Server) listFiles(w http.ResponseWriter, dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		log("%v", err)
+		s.err(w, http.StatusInternalServerError)
+		return
+	}
+
+	r := []fileInfo{}
+	for _, v := range entries {
+		if fi, err := v.Info(); err == nil && fi.Mode().IsRegular() {
+			r = append(r, fileInfo{Name: v.Name(), Size: fi.Size(), Modified: fi.ModTime().UTC()})
+		}
+	}
+	slices.SortFunc(r, func(a, b fileInfo) int { return strings.Compare(a.Name, b.Name) })
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(r)
+}
+
+func (s *Server) download(w http.ResponseWriter, rq *http.Request, dir, name string) {
+	fn := sessionFile(dir, name)
+	if fn == "" {
+		s.err(w, http.StatusBadRequest)
+		return
+	}
+
+	f, err := os.Open(fn)
+	if err != nil {
+		s.err(w, http.StatusNotFound)
+		return
+	}
+
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil || !fi.Mode().IsRegular() {
+		s.err(w, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	http.ServeContent(w, rq, name, fi.ModTime(), f)
+}
+
+func (s *Server) upload(w http.ResponseWriter, rq *http.Request, dir string) {
+	rq.Body = http.MaxBytesReader(w, rq.Body, s.opts.MaxUploadSize)
+	mr, err := rq.MultipartReader()
+	if err != nil {
+		s.err(w, http.StatusBadRequest)
+		return
+	}
+
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			s.err(w, http.StatusBadRequest)
+			return
+		}
+
+		name := filepath.Base(part.FileName())
+		fn := sessionFile(dir, name)
+		if fn == "" {
+			part.Close()
+			continue
+		}
+
+		if err = writeFile(fn, part); err != nil {
+			log("%v", err)
+			s.err(w, http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		if s.opts.Verbose {
+			fmt.Fprintf(os.Stderr, "uploaded %s\n", fn)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeFile atomically replaces 'fn' with the content of 'r'.
+func writeFile(fn string, r io.Reader) (err error) {
+	f, err := os.CreateTemp(filepath.Dir(fn), ".upload-*")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			os.Remove(f.Name())
+		}
+	}()
+
+	if _, err = io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), fn)
+}
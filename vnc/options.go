@@ -0,0 +1,229 @@
+// Copyright 2025 The tk9.0-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vnc // import "modernc.org/tk9.0/vnc"
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	// defaultPort is the default value of Options.Port.
+	defaultPort = 1221
+)
+
+// ErrNotSupported is returned by [NewServer] on targets without an X11
+// backend.
+var ErrNotSupported = errors.New("vnc: not supported on this target")
+
+// Options configure a [Server].
+//
+// Use [DefaultOptions] to obtain the defaults and then change the fields as
+// needed. Empty string and zero duration fields, except IdleTimeout, are
+// replaced by their defaults by [NewServer].
+type Options struct {
+	// Port is the TCP port [Server.Start] listens on. Defaults to 1221.
+	Port int
+
+	// BasePath is the URL path prefix the server handler is mounted at, for
+	// example "/vnc/". Defaults to "/".
+	BasePath string
+
+	// Password, if not empty, is the password VNC clients must provide to
+	// connect.
+	Password string
+
+	// UsePasswordFile makes x11vnc require the password stored on the server
+	// machine, see the -usepw option in man 1 x11vnc.
+	UsePasswordFile bool
+
+	// Quality is the noVNC quality level in [0, 9]. Negative values select
+	// the default provided by x11vnc.
+	Quality int
+
+	// PollInterval is the interval at which the server checks for
+	// disconnected clients. A random duration in [0, PollVariance] is added
+	// on each poll cycle. Default to 30s and 1m.
+	PollInterval time.Duration
+	PollVariance time.Duration
+
+	// XvfbBin and X11vncBin are the paths of the respective programs. Default
+	// to the programs found in $PATH.
+	XvfbBin   string
+	X11vncBin string
+
+	// XrandrBin is the path of the xrandr program used to resize sessions
+	// when the browser window size changes. Defaults to xrandr found in
+	// $PATH. If the program is not available, sessions keep their initial
+	// size.
+	XrandrBin string
+
+	// MaxWidth and MaxHeight limit the screen size of sessions.
+	// Default to 3840 and 2160.
+	MaxWidth  int
+	MaxHeight int
+
+	// App is the path of the application binary started for every client.
+	// Defaults to the current executable.
+	App string
+
+	// Args are the command line arguments passed to App.
+	Args []string
+
+	// Title is the title of the client web page. Defaults to the base name of
+	// App.
+	Title string
+
+	// Verbose enables logging of server events to stderr.
+	Verbose bool
+
+	// Authenticator, if not nil, authenticates every request. The user
+	// identity it returns is passed to the app instance in the EnvVarUser
+	// environment variable. A session can be reached only by the user who
+	// started it.
+	Authenticator Authenticator
+
+	// MaxSessions limits the number of concurrently running app instances.
+	// Zero means no limit other than the number of available X servers.
+	MaxSessions int
+
+	// MaxSessionsPerUser limits the number of concurrently running app
+	// instances of a single user. Zero means no limit.
+	MaxSessionsPerUser int
+
+	// GracePeriod is the time a session is kept running after its last
+	// browser connection closes, allowing to reload the page or to recover
+	// from network problems. A browser reconnects to its session using a
+	// signed cookie. Defaults to one minute.
+	GracePeriod time.Duration
+
+	// CookieSecret is the key used to sign session cookies and spectator
+	// URLs. If empty, a random key is generated by [NewServer]. Servers
+	// sharing a CookieSecret accept each other's signatures.
+	CookieSecret []byte
+
+	// Spectators enables read-only spectator pages. The URL path of the
+	// spectator page of a session is passed to the app instance in the
+	// EnvVarSpectatorPath environment variable. Spectators must still pass
+	// the Authenticator, if any.
+	Spectators bool
+
+	// MaxUploadSize limits the size of files uploaded from the browser to
+	// the session directory, see EnvVarFilesDir. Defaults to 64 MiB.
+	MaxUploadSize int64
+
+	// IdleTimeout, if positive, terminates sessions without user activity
+	// for the given duration. Activity is sampled on every poll cycle, see
+	// PollInterval.
+	IdleTimeout time.Duration
+}
+
+// Authenticator authenticates HTTP requests to a [Server].
+type Authenticator interface {
+	// Authenticate returns the identity of the user making the request or an
+	// error if the request is not authorized.
+	Authenticate(rq *http.Request) (user string, err error)
+}
+
+// AuthenticatorFunc adapts a function to the Authenticator interface.
+type AuthenticatorFunc func(rq *http.Request) (user string, err error)
+
+// Authenticate implements Authenticator.
+func (f AuthenticatorFunc) Authenticate(rq *http.Request) (user string, err error) {
+	return f(rq)
+}
+
+// BasicAuth is an Authenticator using HTTP basic authentication.
+type BasicAuth struct {
+	// Realm is reported to the browser in the authentication challenge.
+	Realm string
+
+	// Check reports whether 'password' is valid for 'user'.
+	Check func(user, password string) bool
+}
+
+// ErrUnauthorized is returned by [BasicAuth] for requests without valid
+// credentials.
+var ErrUnauthorized = errors.New("vnc: unauthorized")
+
+// Authenticate implements Authenticator.
+func (a *BasicAuth) Authenticate(rq *http.Request) (user string, err error) {
+	user, password, ok := rq.BasicAuth()
+	if !ok || a.Check == nil || !a.Check(user, password) {
+		return "", ErrUnauthorized
+	}
+
+	return user, nil
+}
+
+// Challenge returns the value of the WWW-Authenticate header sent with
+// responses to unauthorized requests.
+func (a *BasicAuth) Challenge() string {
+	return fmt.Sprintf("Basic realm=%q, charset=\"UTF-8\"", a.Realm)
+}
+
+// DefaultOptions returns the default options.
+func DefaultOptions() Options {
+	return Options{
+		Port:          defaultPort,
+		BasePath:      "/",
+		Quality:       -1,
+		PollInterval:  30 * time.Second,
+		PollVariance:  time.Minute,
+		GracePeriod:   time.Minute,
+		XvfbBin:       "Xvfb",
+		X11vncBin:     "x11vnc",
+		XrandrBin:     "xrandr",
+		MaxWidth:      3840,
+		MaxHeight:     2160,
+		MaxUploadSize: 64 << 20,
+	}
+}
+
+// setDefaults replaces zero values by the defaults.
+func (o *Options) setDefaults() {
+	d := DefaultOptions()
+	if o.Port == 0 {
+		o.Port = d.Port
+	}
+	if o.BasePath == "" {
+		o.BasePath = d.BasePath
+	}
+	if o.BasePath[0] != '/' {
+		o.BasePath = "/" + o.BasePath
+	}
+	if o.BasePath[len(o.BasePath)-1] != '/' {
+		o.BasePath += "/"
+	}
+	if o.PollInterval == 0 {
+		o.PollInterval = d.PollInterval
+	}
+	if o.PollVariance == 0 {
+		o.PollVariance = d.PollVariance
+	}
+	if o.GracePeriod == 0 {
+		o.GracePeriod = d.GracePeriod
+	}
+	if o.XvfbBin == "" {
+		o.XvfbBin = d.XvfbBin
+	}
+	if o.X11vncBin == "" {
+		o.X11vncBin = d.X11vncBin
+	}
+	if o.XrandrBin == "" {
+		o.XrandrBin = d.XrandrBin
+	}
+	if o.MaxWidth <= 0 {
+		o.MaxWidth = d.MaxWidth
+	}
+	if o.MaxHeight <= 0 {
+		o.MaxHeight = d.MaxHeight
+	}
+	if o.MaxUploadSize <= 0 {
+		o.MaxUploadSize = d.MaxUploadSize
+	}
+}
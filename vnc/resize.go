@@ -0,0 +1,144 @@
+// Copyright 2025 The tk9.0-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build linux || freebsd
+
+package vnc // import "modernc.org/tk9.0/vnc"
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// EnvVarGeometryFile names a file containing the current "<width>x<height>"
+	// of the X screen. It is rewritten when the browser window is resized.
+	EnvVarGeometryFile = "TK9_VNC_GEOMETRY_FILE"
+
+	// resizePath is the path prefix, relative to Options.BasePath, of the
+	// resize endpoints. The full path is resizePath+<client id>_<w>_<h>.
+	resizePath = "resize/"
+
+	minScreenSize = 64
+)
+
+// canResize reports whether sessions support resizing.
+func (s *Server) canResize() bool {
+	return s.xrandr != ""
+}
+
+// screenSize parses the requested screen size. It reports false if the size
+// is not numeric. The result is limited to minScreenSize and to
+// Options.MaxWidth and Options.MaxHeight.
+func (s *Server) screenSize(width, height string) (w, h int, ok bool) {
+	w, err := strconv.Atoi(width)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	if h, err = strconv.Atoi(height); err != nil {
+		return 0, 0, false
+	}
+
+	return max(min(w, s.opts.MaxWidth), minScreenSize), max(min(h, s.opts.MaxHeight), minScreenSize), true
+}
+
+// screenArgs returns the Xvfb arguments for a screen of the size returned by
+// screenSize. With resizing enabled, RANDR is enabled for xrandr to change the
+// size after x11vnc starts.
+func (s *Server) screenArgs(width, height int) []string {
+	r := []string{"-screen", "0", fmt.Sprintf("%dx%dx%d", width, height, depth)}
+	if s.canResize() {
+		r = append(r, "+extension", "RANDR")
+	}
+	return r
+}
+
+// resize changes the frame buffer of the X server of 'c' and publishes the
+// new geometry to the app.
+func (c *client) resize(width, height int) (err error) {
+	width = min(max(width, minScreenSize), c.srv.opts.MaxWidth)
+	height = min(max(height, minScreenSize), c.srv.opts.MaxHeight)
+	geometry := fmt.Sprintf("%dx%d", width, height)
+	for i := 0; i < 10; i++ { // The X server may still be starting.
+		cmd := exec.Command(c.srv.xrandr, "--fb", geometry)
+		cmd.Env = append(os.Environ(), "DISPLAY="+c.display)
+		var b []byte
+		if b, err = cmd.CombinedOutput(); err == nil {
+			break
+		}
+
+		err = fmt.Errorf("xrandr --fb %s: %v: %s", geometry, err, strings.TrimSpace(string(b)))
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err != nil {
+		return err
+	}
+
+	if c.srv.opts.Verbose {
+		fmt.Fprintf(os.Stderr, "resized DISPLAY=%s to %s\n", c.display, geometry)
+	}
+	if c.geometryFile == "" {
+		return nil
+	}
+
+	tmp := c.geometryFile + ".tmp"
+	if err = os.WriteFile(tmp, []byte(geometry), 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, c.geometryFile)
+}
+
+// serveResize handles the resize requests of the noVNC page.
+func (s *Server) serveResize(w http.ResponseWriter, rq *http.Request, arg, user string) {
+	if rq.Method != "POST" {
+		s.err(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	a := strings.Split(arg, "_")
+	if len(a) != 3 {
+		s.err(w, http.StatusBadRequest)
+		return
+	}
+
+	width, err1 := strconv.Atoi(a[1])
+	height, err2 := strconv.Atoi(a[2])
+	if err1 != nil || err2 != nil {
+		s.err(w, http.StatusBadRequest)
+		return
+	}
+
+	id := a[0]
+	c := s.clients.lookup(id)
+	if c == nil || !s.canResize() {
+		s.err(w, http.StatusNotFound)
+		return
+	}
+
+	c.Lock()
+
+	defer c.Unlock()
+
+	switch {
+	case !c.isConnected:
+		s.err(w, http.StatusNotFound)
+	case c.user != user || s.cookieSession(rq) != id:
+		s.err(w, http.StatusForbidden)
+	default:
+		if err := c.resize(width, height); err != nil {
+			log("%v", err)
+			s.err(w, http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2025 The tk9.0-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build linux || freebsd
+
+package vnc // import "modernc.org/tk9.0/vnc"
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestScreenSize(t *testing.T) {
+	s := &Server{opts: Options{MaxWidth: 1920, MaxHeight: 1080}}
+	for i, v := range []struct {
+		width, height string
+		e             string
+	}{
+		{"800", "600", "800 600 true"},
+		{"100000", "100000", "1920 1080 true"},
+		{"1", "-5", "64 64 true"},
+		{"800x", "600", "0 0 false"},
+		{"800", "", "0 0 false"},
+	} {
+		w, h, ok := s.screenSize(v.width, v.height)
+		if g := fmt.Sprint(w, h, ok); g != v.e {
+			t.Errorf("%v: got %s, expected %s", i, g, v.e)
+		}
+	}
+	if g, e := fmt.Sprint(s.screenArgs(800, 600)), "[-screen 0 800x600x16]"; g != e {
+		t.Errorf("got %s, expected %s", g, e)
+	}
+}
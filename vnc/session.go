@@ -0,0 +1,195 @@
+// Copyright 2025 The tk9.0-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build linux || freebsd
+
+package vnc // import "modernc.org/tk9.0/vnc"
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const (
+	// sessionCookie names the cookie binding a browser to its session.
+	sessionCookie = "tk9vnc"
+
+	// viewPath is the path prefix, relative to Options.BasePath, of the
+	// spectator pages. The full path is viewPath+<client id>.<signature>.
+	viewPath = "view/"
+)
+
+// sign returns the signature of 'id' for the purpose 'kind'.
+func (s *Server) sign(kind, id string) string {
+	m := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(m, "%s\x00%s", kind, id)
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+// verify reports whether 'token' has the form <id>.<signature> with a valid
+// signature for 'kind' and returns the id.
+func (s *Server) verify(kind, token string) (id string, ok bool) {
+	id, sig, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(s.sign(kind, id))) {
+		return "", false
+	}
+
+	return id, true
+}
+
+// setSessionCookie binds the browser making the request to session 'id'.
+func (s *Server) setSessionCookie(w http.ResponseWriter, rq *http.Request, id string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    id + "." + s.sign("session", id),
+		Path:     s.opts.BasePath,
+		HttpOnly: true,
+		Secure:   rq.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// cookieSession returns the session id from a valid session cookie of 'rq'.
+func (s *Server) cookieSession(rq *http.Request) string {
+	ck, err := rq.Cookie(sessionCookie)
+	if err != nil {
+		return ""
+	}
+
+	id, _ := s.verify("session", ck.Value)
+	return id
+}
+
+// resumable returns the id of a running session of 'user' the browser making
+// the request can reconnect to, if any.
+func (s *Server) resumable(rq *http.Request, user string) string {
+	id := s.cookieSession(rq)
+	if id == "" {
+		return ""
+	}
+
+	c := s.clients.lookup(id)
+	if c == nil {
+		return ""
+	}
+
+	c.Lock()
+
+	defer c.Unlock()
+
+	if !c.isConnected || c.user != user {
+		return ""
+	}
+
+	return id
+}
+
+// spectatorPath returns the URL path of the spectator page of session 'id'.
+func (s *Server) spectatorPath(id string) string {
+	return s.opts.BasePath + viewPath + id + "." + s.sign("view", id)
+}
+
+// spectate serves the read-only page of the session identified by 'token'.
+func (s *Server) spectate(w http.ResponseWriter, token string) {
+	id, ok := s.verify("view", token)
+	if !s.opts.Spectators || !ok {
+		s.err(w, http.StatusNotFound)
+		return
+	}
+
+	c := s.clients.lookup(id)
+	if c == nil {
+		s.err(w, http.StatusNotFound)
+		return
+	}
+
+	c.Lock()
+
+	defer c.Unlock()
+
+	if !c.isConnected {
+		s.err(w, http.StatusNotFound)
+		return
+	}
+
+	if c.viewCmd == nil {
+		args := []string{"-display", c.display, "-forever", "-shared", "-viewonly", "-autoport", "5900", "-noshm", "-localhost"}
+		var err error
+		if c.viewCmd, c.viewCancel, c.viewPort, err = s.startX11vnc(args); err != nil {
+			log("%v", err)
+			s.spawnFailed("x11vnc")
+			s.err(w, http.StatusFailedDependency)
+			return
+		}
+
+		if s.opts.Verbose {
+			fmt.Fprintf(os.Stderr, "spectator VNC server for DISPLAY=%s listening on :%d\n", c.display, c.viewPort)
+		}
+	}
+	if err := c.page(w, s.opts.BasePath[1:]+wsPath+id+"?view="+s.sign("view", id), true); err != nil {
+		log("%v", err)
+	}
+}
+
+// passwordArgs returns the x11vnc password options. A password is passed in a
+// file readable only by the current user, so it does not show in the process
+// list. x11vnc removes the file after reading it, 'cleanup' removes it in case
+// x11vnc did not get that far.
+func (s *Server) passwordArgs() (args []string, cleanup func(), err error) {
+	cleanup = func() {}
+	switch {
+	case s.opts.Password != "":
+		f, err := os.CreateTemp("", "tk9vnc-*.passwd") // Mode 0600.
+		if err != nil {
+			return nil, cleanup, err
+		}
+
+		fn := f.Name()
+		_, err = fmt.Fprintln(f, s.opts.Password)
+		if err2 := f.Close(); err == nil {
+			err = err2
+		}
+		if err != nil {
+			os.Remove(fn)
+			return nil, cleanup, err
+		}
+
+		return []string{"-passwdfile", "rm:" + fn}, func() { os.Remove(fn) }, nil
+	case s.opts.UsePasswordFile:
+		return []string{"-usepw"}, cleanup, nil
+	default:
+		return []string{"-nopw"}, cleanup, nil
+	}
+}
+
+// page serves the noVNC page of 'c' connecting to the websocket at 'path'.
+func (c *client) page(w http.ResponseWriter, path string, viewOnly bool) error {
+	tArgs := struct {
+		Base       string // Relative URLs are resolved against Options.BasePath.
+		FilesPath  string
+		Path       string
+		Quality    int
+		ResizePath string
+		Title      string
+		ViewOnly   bool
+	}{
+		Base:     c.srv.opts.BasePath,
+		Path:     path,
+		Quality:  c.srv.opts.Quality,
+		Title:    c.srv.opts.Title,
+		ViewOnly: viewOnly,
+	}
+	if !viewOnly && c.srv.canResize() {
+		tArgs.ResizePath = c.srv.opts.BasePath + resizePath + c.id
+	}
+	if !viewOnly && c.filesDir != "" {
+		tArgs.FilesPath = c.srv.opts.BasePath + filesPath + c.id
+	}
+	return c.srv.html.Execute(w, tArgs)
+}
@@ -9,6 +9,7 @@ package vnc // import "modernc.org/tk9.0/vnc"
 import (
 	"bufio"
 	"context"
+	crand "crypto/rand"
 	"embed"
 	"errors"
 	"fmt"
@@ -16,6 +17,7 @@ import (
 	"io"
 	"math"
 	"math/rand"
+	"net"
 	"net/http"
 	"os"
 	"os/exec"
@@ -51,14 +53,21 @@ const (
 	// started.
 	EnvVarInstanceStart = "TK9_VNC_INSTANCE_START"
 
+	// EnvVarUser is set to the user identity returned by
+	// Options.Authenticator. It is empty when no authenticator is
+	// configured.
+	EnvVarUser = "TK9_VNC_USER"
+
+	// EnvVarSpectatorPath is set to the URL path of the read-only spectator
+	// page of the session when Options.Spectators is enabled.
+	EnvVarSpectatorPath = "TK9_VNC_SPECTATOR_PATH"
+
 	// EnvVarInstanceStat is set to the unix milliseconds of the client instance
 	// stat mtime.
 	EnvVarInstanceStat = "TK9_VNC_INSTANCE_STAT"
 
 	// defaultlMaxXServerNumber is the default value of MaxXServerNumber.
 	defaultlMaxXServerNumber = 75
-	// defaultPort is the default value of the -vnc.port CLI flag
-	defaultPort = 1221
 
 	depth = 16 // Xvfb screen depth
 
@@ -69,17 +78,8 @@ const (
 var maxXServerNumber = defaultlMaxXServerNumber
 
 var (
-	appBin        string
-	appTitle      string
-	clients       = newClientRegister()
-	dbg           = os.Getenv("TK9_VNC_DEBUG") != ""
-	mu            sync.Mutex
-	prng          *prng32
-	vncArgs       []string
-	vncHTML       *template.Template
-	websockifyBin = "websockify"
-	x11vncBin     = "x11vnc"
-	xvfbBin       = "Xvfb"
+	dbg = os.Getenv("TK9_VNC_DEBUG") != ""
+	mu  sync.Mutex // Protects X server number allocation and app starts.
 
 	//go:embed embed
 	assets embed.FS
@@ -132,12 +132,12 @@ func allocDisplay() (r int, err error) {
 	return 0, fmt.Errorf("cannot find free X server number")
 }
 
-func (f *flags) start(bin string, args []string, pipe bool, env map[string]string) (cmd *exec.Cmd, cancel context.CancelFunc, stdout io.ReadCloser, err error) {
-	return f.start0(bin, args, pipe, false, env)
+func (s *Server) start(bin string, args []string, pipe bool, env map[string]string) (cmd *exec.Cmd, cancel context.CancelFunc, stdout io.ReadCloser, err error) {
+	return s.start0(bin, args, pipe, false, env)
 }
 
-func (f *flags) start0(bin string, args []string, pipe, silent bool, env map[string]string) (cmd *exec.Cmd, cancel context.CancelFunc, stdout io.ReadCloser, err error) {
-	if !silent && f.verbose {
+func (s *Server) start0(bin string, args []string, pipe, silent bool, env map[string]string) (cmd *exec.Cmd, cancel context.CancelFunc, stdout io.ReadCloser, err error) {
+	if !silent && s.opts.Verbose {
 		defer func() {
 			pid := -1
 			if cmd != nil {
@@ -169,7 +169,7 @@ func (f *flags) start0(bin string, args []string, pipe, silent bool, env map[str
 			return nil, nil, nil, err
 		}
 	}
-	if !silent && f.verbose {
+	if !silent && s.opts.Verbose {
 		cmd.Stderr = os.Stderr
 	}
 	if err := cmd.Start(); err != nil {
@@ -185,18 +185,26 @@ func (f *flags) start0(bin string, args []string, pipe, silent bool, env map[str
 	return cmd, cancel, stdout, nil
 }
 
-func (f *flags) startX11vnc(args []string) (cmd *exec.Cmd, cancel context.CancelFunc, port int, err error) {
-	cmd, cancel, stdout, err := f.start(x11vncBin, args, true, nil)
+func (s *Server) startX11vnc(args []string) (cmd *exec.Cmd, cancel context.CancelFunc, port int, err error) {
+	pw, cleanup, err := s.passwordArgs()
+	if err != nil {
+		log("%v", err)
+		return nil, nil, 0, err
+	}
+
+	defer cleanup()
+
+	cmd, cancel, stdout, err := s.start(s.opts.X11vncBin, append(args, pw...), true, nil)
 	if err != nil {
 		log("%v", err)
 		return nil, nil, 0, err
 	}
 
 	sc := bufio.NewReader(stdout)
-	s, err := sc.ReadString('\n')
-	s = strings.TrimSpace(s)
-	s = strings.TrimPrefix(s, "PORT=")
-	n, err := strconv.ParseUint(s, 10, 32)
+	line, err := sc.ReadString('\n')
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "PORT=")
+	n, err := strconv.ParseUint(line, 10, 32)
 	if err != nil {
 		log("%v", err)
 		return nil, nil, port, err
@@ -207,59 +215,45 @@ func (f *flags) startX11vnc(args []string) (cmd *exec.Cmd, cancel context.Cancel
 }
 
 type flags struct {
-	noVNCQuality int           // 0-9
-	pollInterval time.Duration // Check client connected at pollInterval+rnd(pollVariance)
-	pollVariance time.Duration
-
-	port uint16
-
-	serve          bool
-	verbose        bool
-	x11vncPassword bool
-}
-
-func newFlags() *flags {
-	return &flags{
-		noVNCQuality: -1,
-		port:         defaultPort,
-		pollInterval: 30 * time.Second,
-		pollVariance: time.Minute,
-	}
+	Options
+	serve bool
 }
 
+// parseFlags parses the -vnc.* flags in 'in' and returns the remaining
+// arguments in 'out'.
 func parseFlags(in []string) (r *flags, out []string, err error) {
 	set := opt.NewSet()
-	r = newFlags()
+	r = &flags{Options: DefaultOptions()}
 	set.Arg("vnc.port", false, func(opt, arg string) error {
 		n, err := strconv.ParseUint(arg, 10, 16)
 		if err == nil {
-			r.port = uint16(n)
+			r.Port = int(n)
 		}
 		return err
 	})
 	set.Arg("vnc.poll.interval", false, func(opt, arg string) error {
 		if n, err := time.ParseDuration(arg); err == nil {
-			r.pollInterval = n
+			r.PollInterval = n
 		}
 		return nil
 	})
 	set.Arg("vnc.poll.variance", false, func(opt, arg string) error {
 		if n, err := time.ParseDuration(arg); err == nil {
-			r.pollVariance = n
+			r.PollVariance = n
 		}
 		return nil
 	})
 	set.Arg("vnc.quality", false, func(opt, arg string) error {
 		n, err := strconv.ParseUint(arg, 10, 16)
 		if err == nil && n <= 9 {
-			r.noVNCQuality = int(n)
+			r.Quality = int(n)
 		}
 		return nil
 	})
-	set.Opt("vnc.nopw", func(opt string) error { r.x11vncPassword = false; return nil })
+	set.Opt("vnc.nopw", func(opt string) error { r.UsePasswordFile = false; return nil })
 	set.Opt("vnc.serve", func(opt string) error { r.serve = true; return nil })
-	set.Opt("vnc.usepw", func(opt string) error { r.x11vncPassword = true; return nil })
-	set.Opt("vnc.verbose", func(opt string) error { r.verbose = true; return nil })
+	set.Opt("vnc.usepw", func(opt string) error { r.UsePasswordFile = true; return nil })
+	set.Opt("vnc.verbose", func(opt string) error { r.Verbose = true; return nil })
 	err = errors.Join(err, set.Parse(in, func(opt string) error {
 		switch {
 		case strings.HasPrefix(opt, "-vnc."):
@@ -303,6 +297,7 @@ func lookPath(s string) (r string) {
 	return r
 }
 
+// checkServe implements the -vnc.* command line flags using a Server.
 func checkServe() {
 	flags, out, err := parseFlags(os.Args)
 	os.Args = out
@@ -315,65 +310,247 @@ func checkServe() {
 		return
 	}
 
-	appTitle = filepath.Base(os.Args[0])
-	appTitle = strings.TrimSuffix(appTitle, ".exe")
-	xvfbBin = lookPath(xvfbBin)
-	websockifyBin = lookPath(websockifyBin)
-	x11vncBin = lookPath(x11vncBin)
-	b, err := assets.ReadFile("embed/vnc.html")
+	flags.Args = os.Args[1:]
+	s, err := NewServer(flags.Options)
 	if err != nil {
 		log("%v", err)
-		return
+		os.Exit(1)
 	}
 
-	if vncHTML, err = template.New("vnc").Parse(string(b)); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		return
+	if err = s.listenAndServe(); err != nil {
+		log("%v", err)
+		os.Exit(1)
 	}
+}
 
-	if prng, err = newPrng32(); err != nil {
-		log("%v", err)
-		return
+// Server is a VNC over websockets server. For every connecting web client it
+// starts a new instance of the application in its own X server and serves a
+// noVNC web page connected to it.
+type Server struct {
+	clients *clientRegister
+	html    *template.Template
+	opts    Options
+	prng    *prng32
+	secret  []byte // Key of the cookie and spectator URL signatures.
+	xrandr  string // Path of xrandr if sessions can be resized.
+
+	sync.Mutex
+	metrics      metrics        //
+	sessions     int            // Running app instances.
+	srv          *http.Server   //
+	userSessions map[string]int // Running app instances per user.
+}
+
+// NewServer returns a new Server configured by 'opts'.
+func NewServer(opts Options) (s *Server, err error) {
+	opts.setDefaults()
+	if opts.App == "" {
+		if opts.App, err = os.Executable(); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Title == "" {
+		opts.Title = strings.TrimSuffix(filepath.Base(opts.App), ".exe")
+	}
+	opts.XvfbBin = lookPath(opts.XvfbBin)
+	opts.X11vncBin = lookPath(opts.X11vncBin)
+	s = &Server{clients: newClientRegister(), opts: opts, userSessions: map[string]int{}}
+	if opts.XrandrBin != "" {
+		s.xrandr, _ = exec.LookPath(opts.XrandrBin)
+	}
+	b, err := assets.ReadFile("embed/vnc.html")
+	if err != nil {
+		return nil, err
 	}
 
-	prng.prng.Seed(time.Now().UnixNano())
-	if appBin, err = os.Executable(); err != nil {
-		log("%v", err)
-		return
+	if s.html, err = template.New("vnc").Parse(string(b)); err != nil {
+		return nil, err
 	}
 
-	http.Handle("/", flags)
-	if flags.verbose {
-		fmt.Fprintf(os.Stderr, "HTTP server listening at :%d\n", flags.port)
+	if s.secret = opts.CookieSecret; len(s.secret) == 0 {
+		s.secret = make([]byte, 32)
+		if _, err = crand.Read(s.secret); err != nil {
+			return nil, err
+		}
 	}
-	if err = http.ListenAndServe(fmt.Sprintf(":%d", flags.port), nil); err != nil {
-		log("%v", err)
-		os.Exit(1)
+
+	if s.prng, err = newPrng32(); err != nil {
+		return nil, err
+	}
+
+	s.prng.prng.Seed(time.Now().UnixNano())
+	return s, nil
+}
+
+// Handler returns the HTTP handler of 's'. It serves requests for paths
+// starting with Options.BasePath, for example
+//
+//	http.Handle("/vnc/", s.Handler())
+func (s *Server) Handler() http.Handler {
+	return s
+}
+
+// Start starts serving HTTP on Options.Port in a new goroutine using a
+// dedicated http.Server. It returns after the listening socket is ready.
+func (s *Server) Start() (err error) {
+	ln, err := s.listen()
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		if err := s.serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log("%v", err)
+		}
+	}()
+	return nil
+}
+
+func (s *Server) listenAndServe() error {
+	ln, err := s.listen()
+	if err != nil {
+		return err
 	}
+
+	return s.serve(ln)
 }
 
-func (f *flags) err(w http.ResponseWriter, code int) {
+func (s *Server) listen() (ln net.Listener, err error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.srv != nil {
+		return nil, fmt.Errorf("vnc: server already started")
+	}
+
+	if ln, err = net.Listen("tcp", fmt.Sprintf(":%d", s.opts.Port)); err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(s.opts.BasePath, s)
+	s.srv = &http.Server{Handler: mux}
+	if s.opts.Verbose {
+		fmt.Fprintf(os.Stderr, "HTTP server listening at :%d\n", s.opts.Port)
+	}
+	return ln, nil
+}
+
+func (s *Server) serve(ln net.Listener) error {
+	s.Lock()
+	srv := s.srv
+	s.Unlock()
+	return srv.Serve(ln)
+}
+
+// Shutdown stops the HTTP server started by Start, if any, and terminates
+// all client sessions and their application instances.
+func (s *Server) Shutdown(ctx context.Context) (err error) {
+	s.Lock()
+	srv := s.srv
+	s.srv = nil
+	s.Unlock()
+	if srv != nil {
+		err = srv.Shutdown(ctx)
+	}
+	for _, c := range s.clients.all() {
+		c.disconnect()
+		s.clients.delete(c.id)
+	}
+	return err
+}
+
+func (s *Server) err(w http.ResponseWriter, code int) {
 	http.Error(w, http.StatusText(code), code)
 }
 
-func (f *flags) ServeHTTP(w http.ResponseWriter, rq *http.Request) {
-	if rq.Method != "GET" {
-		f.err(w, http.StatusMethodNotAllowed)
+// authenticate returns the user identity of 'rq'. It reports false after
+// writing the error response if the request is not authorized.
+func (s *Server) authenticate(w http.ResponseWriter, rq *http.Request) (user string, ok bool) {
+	if s.opts.Authenticator == nil {
+		return "", true
+	}
+
+	user, err := s.opts.Authenticator.Authenticate(rq)
+	if err != nil {
+		if s.opts.Verbose {
+			fmt.Fprintf(os.Stderr, "authentication failed: %s: %v\n", rq.RemoteAddr, err)
+		}
+		if c, ok := s.opts.Authenticator.(interface{ Challenge() string }); ok {
+			w.Header().Set("WWW-Authenticate", c.Challenge())
+		}
+		s.err(w, http.StatusUnauthorized)
+		return "", false
+	}
+
+	return user, true
+}
+
+// reserve accounts for a new session of 'user'. It reports false if that
+// would exceed the session limits.
+func (s *Server) reserve(user string) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	if n := s.opts.MaxSessions; n > 0 && s.sessions >= n {
+		return false
+	}
+
+	if n := s.opts.MaxSessionsPerUser; n > 0 && s.userSessions[user] >= n {
+		return false
+	}
+
+	s.sessions++
+	s.userSessions[user]++
+	return true
+}
+
+// release undoes reserve.
+func (s *Server) release(user string) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.sessions--
+	if s.userSessions[user]--; s.userSessions[user] <= 0 {
+		delete(s.userSessions, user)
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, rq *http.Request) {
+	if rq.Method != "GET" && rq.Method != "POST" {
+		s.err(w, http.StatusMethodNotAllowed)
 		return
 	}
 
 	if dbg {
 		trc("GET %q %q %q", rq.URL.Path, rq.Host, rq.Header)
 	}
+	if !strings.HasPrefix(rq.URL.Path, s.opts.BasePath) {
+		s.err(w, http.StatusNotFound)
+		return
+	}
+
+	user, ok := s.authenticate(w, rq)
+	if !ok {
+		return
+	}
+
+	p := "/" + rq.URL.Path[len(s.opts.BasePath):]
+	if rq.Method == "POST" && !strings.HasPrefix(p, "/"+resizePath) && !strings.HasPrefix(p, "/"+filesPath) {
+		s.err(w, http.StatusMethodNotAllowed)
+		return
+	}
+
 	switch {
-	case rq.URL.Path == "/":
-		f.connect(w)
+	case p == "/":
+		s.connect(w, s.resumable(rq, user))
 	case
-		strings.HasPrefix(rq.URL.Path, "/core/"),
-		strings.HasPrefix(rq.URL.Path, "/favicon"),
-		strings.HasPrefix(rq.URL.Path, "/vendor/"):
+		strings.HasPrefix(p, "/core/"),
+		strings.HasPrefix(p, "/favicon"),
+		strings.HasPrefix(p, "/vendor/"):
 
-		p := path.Join("embed", strings.ReplaceAll(rq.URL.Path, "/vendor/", "/vendor_/"))
+		p := path.Join("embed", strings.ReplaceAll(p, "/vendor/", "/vendor_/"))
 		if dbg {
 			f, err := assets.Open(p)
 			trc("%q -> (%p, %v)", p, f, err)
@@ -382,64 +559,112 @@ func (f *flags) ServeHTTP(w http.ResponseWriter, rq *http.Request) {
 			}
 		}
 		http.ServeFileFS(w, rq, assets, p)
+	case strings.HasPrefix(p, "/"+wsPath):
+		s.bridge(w, rq, p[len(wsPath)+1:], user)
+	case strings.HasPrefix(p, "/"+resizePath):
+		s.serveResize(w, rq, p[len(resizePath)+1:], user)
+	case strings.HasPrefix(p, "/"+filesPath):
+		s.serveFiles(w, rq, p[len(filesPath)+1:], user)
+	case strings.HasPrefix(p, "/"+viewPath):
+		s.spectate(w, p[len(viewPath)+1:])
 	default:
-		a := strings.Split(rq.URL.Path, "_")
+		a := strings.Split(p[1:], "_")
 		if len(a) != 3 {
-			f.err(w, http.StatusBadRequest)
+			s.err(w, http.StatusBadRequest)
 			return
 		}
 
 		clientID := a[0]
-		width := a[1]
-		height := a[2]
-		c := clients.get(clientID)
+		width, height, ok := s.screenSize(a[1], a[2])
+		if !ok {
+			s.err(w, http.StatusBadRequest)
+			return
+		}
+
+		c := s.clients.get(clientID)
 
 		defer c.Unlock()
 
-		if c.isConnected || c.disconnected {
-			f.connect(w)
+		if c.isConnected {
+			if c.user != user || s.cookieSession(rq) != clientID {
+				s.err(w, http.StatusForbidden)
+				return
+			}
+
+			// Reconnect to the running session.
+			if err := c.page(w, s.opts.BasePath[1:]+wsPath+clientID, false); err != nil {
+				log("%v", err)
+			}
 			return
 		}
 
-		host := rq.Host
-		if x := strings.IndexByte(host, ':'); x != 0 {
-			host = host[:x]
+		if c.disconnected {
+			s.connect(w, "")
+			return
+		}
+
+		if !s.reserve(user) {
+			if s.opts.Verbose {
+				fmt.Fprintf(os.Stderr, "session limit reached: user=%q\n", user)
+			}
+			s.err(w, http.StatusTooManyRequests)
+			return
+		}
+
+		c.srv = s
+		c.user = user
+		if c.connect(w, clientID, width, height, rq); !c.isConnected {
+			s.release(user)
 		}
-		c.flags = f
-		c.connect(w, host, clientID, width, height, rq)
 	}
 }
 
-func (f *flags) connect(w http.ResponseWriter) {
+// connect serves the page redirecting the browser to session 'id' or to a new
+// session if 'id' is empty.
+func (s *Server) connect(w http.ResponseWriter, id string) {
+	if id == "" {
+		id = fmt.Sprint(s.prng.id())
+	}
 	fmt.Fprintf(w, `<!DOCTYPE html>
 <html lang="en">
 <head>
     <script>
 	    function bodyOnload() {
-		    window.location.assign(%s/%d_${window.innerWidth}_${window.innerHeight}%[1]s)
+		    window.location.assign(%s%s%s_${window.innerWidth}_${window.innerHeight}%[1]s)
 	    }
     </script>
 </head>
 <body style="background-color:#eee;margin:0;min-width:100vw;min-height:100vh" onload="bodyOnload();">
 </body>
-</html>`, "`", prng.id())
+</html>`, "`", s.opts.BasePath, id)
 }
 
 type client struct {
 	sync.Mutex
 
-	appCancel        context.CancelFunc
-	appCmd           *exec.Cmd
-	display          string // :1, :2, ...
-	flags            *flags
-	id               string
-	port             int
-	websockifyCancel context.CancelFunc
-	websockifyCmd    *exec.Cmd
-	x11vncCancel     context.CancelFunc
-	x11vncCmd        *exec.Cmd
-	xvfbCancel       context.CancelFunc
-	xvfbCmd          *exec.Cmd
+	appCancel    context.CancelFunc
+	appCmd       *exec.Cmd
+	display      string // :1, :2, ...
+	filesDir     string // See EnvVarFilesDir.
+	geometryFile string // Published screen size, see EnvVarGeometryFile.
+	id           string
+	lastActivity time.Time
+	pointer      string // Last observed pointer position.
+	port         int
+	remoteAddr   string
+	started      time.Time
+	srv          *Server
+	user         string
+	userAgent    string
+	viewCancel   context.CancelFunc // Spectator x11vnc.
+	viewCmd      *exec.Cmd
+	viewPort     int
+	viewers      int       // Open owner websocket connections.
+	viewersSeen  time.Time // Last time viewers was not zero.
+	x11vncCancel context.CancelFunc
+	x11vncCmd    *exec.Cmd
+	xvfbCancel   context.CancelFunc
+	xvfbCmd      *exec.Cmd
 
 	disconnected bool
 	isConnected  bool
@@ -458,7 +683,7 @@ func cancel(f ...func()) {
 }
 
 func (c *client) after() <-chan time.Time {
-	return time.After(c.flags.pollInterval + time.Duration(rand.Int63n(int64(c.flags.pollVariance))))
+	return time.After(c.srv.opts.PollInterval + time.Duration(rand.Int63n(int64(c.srv.opts.PollVariance))))
 }
 
 func (c *client) poll() {
@@ -468,29 +693,27 @@ func (c *client) poll() {
 			c.Lock()
 
 			if c.disconnected {
-				clients.delete(c.id)
+				c.srv.clients.delete(c.id)
 				c.Unlock()
 				return
 			}
 
-			cmd, cancel, stdout, err := c.flags.start0(x11vncBin, []string{"-query", "client_count", "-display", c.display}, true, true, nil)
-			if err != nil {
-				log("%v", err)
-				c.Unlock()
-				continue
+			if c.viewers != 0 {
+				c.viewersSeen = time.Now()
 			}
-
-			sc := bufio.NewReader(stdout)
-			s, err := sc.ReadString('\n')
-			cancel()
-			cmd.Wait()
-			if err != nil {
-				log("%v", err)
+			if time.Since(c.viewersSeen) > c.srv.opts.GracePeriod {
+				if c.srv.opts.Verbose {
+					fmt.Fprintf(os.Stderr, "no viewers: DISPLAY=%v\n", c.display)
+				}
 				c.Unlock()
-				continue
+				c.disconnect()
+				return
 			}
 
-			if s = strings.TrimSpace(s); strings.HasSuffix(s, ":0") {
+			if c.idle() {
+				if c.srv.opts.Verbose {
+					fmt.Fprintf(os.Stderr, "idle timeout: DISPLAY=%v\n", c.display)
+				}
 				c.Unlock()
 				c.disconnect()
 				return
@@ -501,6 +724,31 @@ func (c *client) poll() {
 	}
 }
 
+// idle reports whether the client exceeded Options.IdleTimeout. User activity
+// is detected by changes of the pointer position as reported by x11vnc.
+func (c *client) idle() bool {
+	if c.srv.opts.IdleTimeout <= 0 {
+		return false
+	}
+
+	cmd, cancel, stdout, err := c.srv.start0(c.srv.opts.X11vncBin, []string{"-query", "pointer_pos", "-display", c.display}, true, true, nil)
+	if err != nil {
+		log("%v", err)
+		return false
+	}
+
+	s, err := bufio.NewReader(stdout).ReadString('\n')
+	cancel()
+	cmd.Wait()
+	if s = strings.TrimSpace(s); err == nil && s != c.pointer {
+		c.pointer = s
+		c.lastActivity = time.Now()
+		return false
+	}
+
+	return time.Since(c.lastActivity) > c.srv.opts.IdleTimeout
+}
+
 func (c *client) disconnect1(cf *context.CancelFunc, pcmd **exec.Cmd) {
 	defer func() {
 		*cf = nil
@@ -529,15 +777,25 @@ func (c *client) disconnect() {
 	defer func() {
 		c.disconnected = true
 		c.isConnected = false
+		c.srv.release(c.user)
+		c.srv.sessionEnded(time.Since(c.started))
 	}()
 
-	if c.flags.verbose {
+	if c.srv.opts.Verbose {
 		fmt.Fprintf(os.Stderr, "disconnecting DISPLAY=%v\n", c.display)
 	}
 	c.disconnect1(&c.appCancel, &c.appCmd)
-	c.disconnect1(&c.websockifyCancel, &c.websockifyCmd)
+	c.disconnect1(&c.viewCancel, &c.viewCmd)
 	c.disconnect1(&c.x11vncCancel, &c.x11vncCmd)
 	c.disconnect1(&c.xvfbCancel, &c.xvfbCmd)
+	if c.geometryFile != "" {
+		os.Remove(c.geometryFile)
+		c.geometryFile = ""
+	}
+	if c.filesDir != "" {
+		os.RemoveAll(c.filesDir)
+		c.filesDir = ""
+	}
 	tmp := os.TempDir()
 	if !strings.HasPrefix(tmp, "/") || tmp == "/" {
 		panic(todo("internal error"))
@@ -545,76 +803,87 @@ func (c *client) disconnect() {
 
 	display := c.display[1:]
 	arg := fmt.Sprintf("rm -rf '%s'", filepath.Join(tmp, fmt.Sprintf(".X%s-lock", display)))
-	if c.flags.verbose {
+	if c.srv.opts.Verbose {
 		fmt.Fprintf(os.Stderr, "exec `sh -c %s`\n", arg)
 	}
 	exec.Command("sh", "-c", arg).Run()
 }
 
-func (c *client) connect(w http.ResponseWriter, host, id, width, height string, rq *http.Request) {
+func (c *client) connect(w http.ResponseWriter, id string, width, height int, rq *http.Request) {
 	defer func() {
 		if c.isConnected {
 			go c.poll()
 			return
 		}
 
-		cancel(c.appCancel, c.websockifyCancel, c.x11vncCancel, c.xvfbCancel)
+		cancel(c.appCancel, c.x11vncCancel, c.xvfbCancel)
 		c.appCancel = nil
 		c.appCmd = nil
-		c.websockifyCancel = nil
-		c.websockifyCmd = nil
 		c.x11vncCancel = nil
 		c.x11vncCmd = nil
 		c.xvfbCancel = nil
 		c.xvfbCmd = nil
+		if c.geometryFile != "" {
+			os.Remove(c.geometryFile)
+			c.geometryFile = ""
+		}
+		if c.filesDir != "" {
+			os.RemoveAll(c.filesDir)
+			c.filesDir = ""
+		}
 	}()
 
 	c.id = id
 	displayNum, err := allocDisplay()
 	if err != nil {
 		log("%v", err)
+		c.srv.spawnFailed("display")
 		http.Error(w, "cannot allocate new X server", http.StatusTooManyRequests)
 		return
 	}
 
 	display := fmt.Sprintf(":%d", displayNum)
-	args := []string{display, "-screen", "0", fmt.Sprintf("%sx%sx%d", width, height, depth)}
-	if c.xvfbCmd, c.xvfbCancel, _, err = c.flags.start(xvfbBin, args, false, nil); err != nil {
+	args := append([]string{display}, c.srv.screenArgs(width, height)...)
+	if c.xvfbCmd, c.xvfbCancel, _, err = c.srv.start(c.srv.opts.XvfbBin, args, false, nil); err != nil {
 		log("%v", err)
+		c.srv.spawnFailed("Xvfb")
 		http.Error(w, "cannot create new X server", http.StatusFailedDependency)
 		return
 	}
 
-	args = []string{"-display", display, "-forever", "-autoport", "5900", "-noshm"}
-	switch {
-	case c.flags.x11vncPassword:
-		args = append(args, "-usepw")
-	default:
-		args = append(args, "-nopw")
+	args = []string{"-display", display, "-forever", "-shared", "-autoport", "5900", "-noshm", "-localhost"}
+	if c.srv.canResize() {
+		args = append(args, "-xrandr", "newfbsize")
 	}
-	if c.x11vncCmd, c.x11vncCancel, c.port, err = c.flags.startX11vnc(args); err != nil {
+	if c.x11vncCmd, c.x11vncCancel, c.port, err = c.srv.startX11vnc(args); err != nil {
 		log("%v", err)
+		c.srv.spawnFailed("x11vnc")
 		http.Error(w, "cannot create new VNC server", http.StatusFailedDependency)
 		return
 	}
 
-	args = []string{fmt.Sprint(c.port), fmt.Sprintf("localhost:%d", c.port)}
-	if c.websockifyCmd, c.websockifyCancel, _, err = c.flags.start(websockifyBin, args, false, nil); err != nil {
-		log("%v", err)
-		http.Error(w, "cannot start websockify", http.StatusFailedDependency)
-		return
+	c.display = display
+	if c.srv.canResize() {
+		if f, err := os.CreateTemp("", "tk9vnc-*.geometry"); err == nil {
+			f.Close()
+			c.geometryFile = f.Name()
+		}
+		if err = c.resize(width, height); err != nil {
+			log("%v", err)
+			c.srv.spawnFailed("xrandr")
+			http.Error(w, "cannot resize X server", http.StatusFailedDependency)
+			return
+		}
 	}
 
-	tArgs := struct {
-		Port    int
-		Quality int
-		Title   string
-	}{
-		Port:    c.port,
-		Quality: c.flags.noVNCQuality,
-		Title:   appTitle,
+	switch dir, err := os.MkdirTemp("", "tk9vnc-*-files"); {
+	case err != nil:
+		log("%v", err)
+	default:
+		c.filesDir = dir
 	}
-	if err = vncHTML.Execute(w, tArgs); err != nil {
+	c.srv.setSessionCookie(w, rq, id)
+	if err = c.page(w, c.srv.opts.BasePath[1:]+wsPath+id, false); err != nil {
 		log("%v", err)
 		http.Error(w, "cannot execute html template", http.StatusInternalServerError)
 		return
@@ -633,31 +902,47 @@ func (c *client) connect(w http.ResponseWriter, host, id, width, height string,
 	}
 	m["DISPLAY"] = display
 	m[EnvVarVNC] = "1"
+	m[EnvVarUser] = c.user
+	if c.srv.opts.Spectators {
+		m[EnvVarSpectatorPath] = c.srv.spectatorPath(id)
+	}
 	m["TK9_VNC_WIDTH"] = fmt.Sprint(width)
 	m["TK9_VNC_HEIGHT"] = fmt.Sprint(height)
 	m["TK9_VNC_DEPTH"] = fmt.Sprint(depth)
+	if c.geometryFile != "" {
+		m[EnvVarGeometryFile] = c.geometryFile
+	}
+	if c.filesDir != "" {
+		m[EnvVarFilesDir] = c.filesDir
+	}
 	m[EnvVarInstanceStart] = fmt.Sprint(time.Now().UTC().UnixMilli())
-	if fi, err := os.Stat(appBin); err == nil {
+	if fi, err := os.Stat(c.srv.opts.App); err == nil {
 		m[EnvVarInstanceStat] = fmt.Sprint(fi.ModTime().UTC().UnixMilli())
 	}
-	if c.appCmd, c.appCancel, _, err = c.flags.start(appBin, os.Args[1:], false, m); err != nil {
+	if c.appCmd, c.appCancel, _, err = c.srv.start(c.srv.opts.App, c.srv.opts.Args, false, m); err != nil {
 		log("%v", err)
+		c.srv.spawnFailed("app")
 		http.Error(w, "cannot start new application instance", http.StatusFailedDependency)
 		return
 	}
 
-	c.display = display
+	c.lastActivity = time.Now()
+	c.viewersSeen = c.lastActivity
+	c.started = c.lastActivity
+	c.remoteAddr = rq.RemoteAddr
+	c.userAgent = rq.UserAgent()
+	c.srv.sessionStarted()
 
 	go func(c *client) {
 		pid := c.appCmd.Process.Pid
 		c.appCmd.Wait()
-		if c.flags.verbose {
+		if c.srv.opts.Verbose {
 			fmt.Fprintf(os.Stderr, "client exited: DISPLAY=%v PID=%v\n", c.display, pid)
 		}
 		c.disconnect()
 	}(c)
 
-	if c.flags.verbose {
+	if c.srv.opts.Verbose {
 		fmt.Fprintf(os.Stderr, "VNC server for DISPLAY=%s listening on :%d\n", display, c.port)
 	}
 	c.isConnected = true
@@ -698,6 +983,27 @@ func (c *clientRegister) get(id string) (r *client) {
 	return r
 }
 
+// lookup returns the client registered as 'id' or nil.
+func (c *clientRegister) lookup(id string) *client {
+	c.Lock()
+
+	defer c.Unlock()
+
+	return c.m[id]
+}
+
+// all returns the registered clients.
+func (c *clientRegister) all() (r []*client) {
+	c.Lock()
+
+	defer c.Unlock()
+
+	for _, v := range c.m {
+		r = append(r, v)
+	}
+	return r
+}
+
 func (c *clientRegister) delete(id string) {
 	c.Lock()
 
@@ -0,0 +1,50 @@
+// Copyright 2025 The tk9.0-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !(linux || freebsd)
+
+package vnc // import "modernc.org/tk9.0/vnc"
+
+import (
+	"context"
+	"net/http"
+)
+
+// Server is a VNC over websockets server. It is not supported on this target.
+type Server struct{}
+
+// NewServer returns ErrNotSupported on this target.
+func NewServer(opts Options) (*Server, error) {
+	return nil, ErrNotSupported
+}
+
+// Handler returns a handler responding with 501 Not Implemented.
+func (s *Server) Handler() http.Handler {
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, rq *http.Request) {
+	http.Error(w, ErrNotSupported.Error(), http.StatusNotImplemented)
+}
+
+// AdminHandler returns a handler responding with 501 Not Implemented.
+func (s *Server) AdminHandler() http.Handler {
+	return s
+}
+
+// MetricsHandler returns a handler responding with 501 Not Implemented.
+func (s *Server) MetricsHandler() http.Handler {
+	return s
+}
+
+// Start returns ErrNotSupported on this target.
+func (s *Server) Start() error {
+	return ErrNotSupported
+}
+
+// Shutdown does nothing on this target.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return nil
+}
@@ -5,12 +5,15 @@
 package tk9_0 // import "modernc.org/tk9.0"
 
 import (
-	"errors"
 	"fmt"
 )
 
 // OperationCallback is the signature for a WidgetProxy operation callback.
-type OperationCallback func(args []string)
+//
+// The result is returned to the caller of the operation. A non-nil error
+// makes the operation fail with the error message, for example to veto an
+// insertion.
+type OperationCallback func(args []string) (result string, err error)
 
 // widgetProxy wraps a widget window, providing the means to intercept its
 // internal operations, and modify their behaviour.
@@ -26,10 +29,6 @@ type widgetProxy struct {
 // newWidgetProxy creates a proxy that facilitates hooking in to
 // a widget window's internal operations.
 func newWidgetProxy(window *Window) widgetProxy {
-	if !extensionInitialized("eval") {
-		fail(errors.New("use of WidgetProxy requires the 'eval' extension to be enabled, but it is not"))
-	}
-
 	proxy := widgetProxy{
 		window:       window,
 		originalPath: window.String() + "_original",
@@ -66,7 +65,9 @@ func (proxy *widgetProxy) Close() {
 //
 // The operation's arguments are passed to the callback. The callback may perform
 // the operation on the wrapped widget by calling the EvalWrapped method with the
-// arguments. The arguments may be modified if desired.
+// arguments. The arguments may be modified if desired. Callbacks of operations
+// whose result matters, like 'index', 'get' or 'search', should return the
+// result of EvalWrapped.
 func (proxy *widgetProxy) Register(operation string, callback OperationCallback) {
 	proxy.operations[operation] = callback
 }
@@ -77,9 +78,28 @@ func (proxy *widgetProxy) Unregister(operation string) {
 	delete(proxy.operations, operation)
 }
 
-// EvalWrapped evaluates the arguments as a raw Tcl string against the wrapped Window.
-func (proxy *widgetProxy) EvalWrapped(args []string) {
-	evalErr(fmt.Sprintf("%s %s", proxy.originalPath, tclSafeStrings(args...)))
+// EvalWrapped evaluates the arguments as a raw Tcl string against the wrapped
+// Window and returns the result.
+func (proxy *widgetProxy) EvalWrapped(args []string) (r string, err error) {
+	return eval(fmt.Sprintf("%s %s", proxy.originalPath, tclSafeStrings(args...)))
+}
+
+// dispatch performs the operation args[0] using its registered callback, if
+// any, or the wrapped widget. It returns the Tcl result and return code.
+func (proxy *widgetProxy) dispatch(args []string) (r string, code int) {
+	var err error
+	if callback, ok := proxy.operations[args[0]]; ok {
+		// Dispatch to the operation's registered callback.
+		r, err = callback(args)
+	} else {
+		// Process as normal.
+		r, err = proxy.EvalWrapped(args)
+	}
+	if err != nil {
+		return err.Error(), tcl_error
+	}
+
+	return r, tcl_ok
 }
 
 // TextWidgetProxy wraps a TextWidget.
@@ -92,12 +112,78 @@ type TextWidgetProxy struct {
 
 // NewTextWidgetProxy creates a TextWidgetProxy, wrapping the
 // provided TextWidget.
-//
-// Note that because the EvalWrapped method evaluates raw Tcl, the "eval" extension
-// must be enabled in the main package before calling this function.
 func NewTextWidgetProxy(widget *TextWidget) TextWidgetProxy {
 	return TextWidgetProxy{
 		TextWidget:  widget,
 		widgetProxy: newWidgetProxy(widget.Window),
 	}
 }
+
+// EntryWidgetProxy wraps an EntryWidget.
+// It provides the ability to intercept the widget's internal operations (such as
+// 'insert' and 'delete'), for example to implement input masks.
+type EntryWidgetProxy struct {
+	*EntryWidget
+	widgetProxy
+}
+
+// NewEntryWidgetProxy creates an EntryWidgetProxy, wrapping the
+// provided EntryWidget.
+func NewEntryWidgetProxy(widget *EntryWidget) EntryWidgetProxy {
+	return EntryWidgetProxy{
+		EntryWidget: widget,
+		widgetProxy: newWidgetProxy(widget.Window),
+	}
+}
+
+// TEntryWidgetProxy wraps a TEntryWidget.
+// It provides the ability to intercept the widget's internal operations (such as
+// 'insert' and 'delete'), for example to implement input masks.
+type TEntryWidgetProxy struct {
+	*TEntryWidget
+	widgetProxy
+}
+
+// NewTEntryWidgetProxy creates a TEntryWidgetProxy, wrapping the
+// provided TEntryWidget.
+func NewTEntryWidgetProxy(widget *TEntryWidget) TEntryWidgetProxy {
+	return TEntryWidgetProxy{
+		TEntryWidget: widget,
+		widgetProxy:  newWidgetProxy(widget.Window),
+	}
+}
+
+// ListboxWidgetProxy wraps a ListboxWidget.
+// It provides the ability to intercept the widget's internal operations (such as
+// 'insert' and 'delete'), for example to keep a Go model in sync with the
+// listbox items.
+type ListboxWidgetProxy struct {
+	*ListboxWidget
+	widgetProxy
+}
+
+// NewListboxWidgetProxy creates a ListboxWidgetProxy, wrapping the
+// provided ListboxWidget.
+func NewListboxWidgetProxy(widget *ListboxWidget) ListboxWidgetProxy {
+	return ListboxWidgetProxy{
+		ListboxWidget: widget,
+		widgetProxy:   newWidgetProxy(widget.Window),
+	}
+}
+
+// CanvasWidgetProxy wraps a CanvasWidget.
+// It provides the ability to intercept the widget's internal operations (such as
+// 'create', 'delete' and 'coords'), for example to log changes for undo.
+type CanvasWidgetProxy struct {
+	*CanvasWidget
+	widgetProxy
+}
+
+// NewCanvasWidgetProxy creates a CanvasWidgetProxy, wrapping the
+// provided CanvasWidget.
+func NewCanvasWidgetProxy(widget *CanvasWidget) CanvasWidgetProxy {
+	return CanvasWidgetProxy{
+		CanvasWidget: widget,
+		widgetProxy:  newWidgetProxy(widget.Window),
+	}
+}
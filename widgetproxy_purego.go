@@ -27,16 +27,9 @@ func (proxy *widgetProxy) eventDispatcher(clientData, in uintptr, argc int32, ar
 		args[i] = goString(argsPointers[i])
 	}
 
-	operation := args[0]
-	if callback, ok := proxy.operations[operation]; ok {
-		// Dispatch to the operation's registered callback.
-		callback(args)
-	} else {
-		// Process as normal.
-		proxy.EvalWrapped(args)
-	}
-
-	return tcl_ok
+	r, code := proxy.dispatch(args)
+	setResult(r)
+	return uintptr(code)
 }
 
 // Create a new Tcl command whose name is the widget's pathname, and
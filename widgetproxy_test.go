@@ -5,20 +5,14 @@
 package tk9_0 // import "modernc.org/tk9.0"
 
 import (
+	"errors"
+	"fmt"
 	"strings"
 	"testing"
 )
 
 func TestWidgetProxy(t *testing.T) {
-	// Widget proxying checks that the eval extension is initialized.
-	// However it can't be initialized by calling InitializeExtension() here,
-	// as that requires the extension to have been registered. But importing the
-	// modernc.org/tk9.0/extensions/eval package would result in an import cycle.
-	//
-	// Instead let's be a little naughty, and simply pretend that it's initialized.
-	Extensions[ExtensionKey{Name: "eval"}] = &extension{initialized: true}
-
-	return
+	needTk(t)
 	text := NewTextWidgetProxy(Text())
 
 	assertContent := func(expected string) {
@@ -28,9 +22,9 @@ func TestWidgetProxy(t *testing.T) {
 		}
 	}
 
-	insertCallback := func(args []string) {
+	insertCallback := func(args []string) (string, error) {
 		args[2] = strings.ToUpper(args[2]) // upper case the text to be inserted
-		text.EvalWrapped(args)
+		return text.EvalWrapped(args)
 	}
 
 	// no callback registered
@@ -57,3 +51,47 @@ func TestWidgetProxy(t *testing.T) {
 	text.Insert(END, "mno ")
 	assertContent("abc DEF ghi JKL mno ")
 }
+
+func TestWidgetProxyDispatch(t *testing.T) {
+	proxy := widgetProxy{operations: map[string]OperationCallback{
+		"index": func(args []string) (string, error) { return fmt.Sprintf("1.%d", len(args)), nil },
+		"insert": func(args []string) (string, error) {
+			return "ignored", errors.New("read only")
+		},
+	}}
+	for i, v := range []struct {
+		args   []string
+		result string
+		code   int
+	}{
+		{[]string{"index", "end"}, "1.2", tcl_ok},
+		{[]string{"insert", "end", "x"}, "read only", tcl_error},
+	} {
+		r, code := proxy.dispatch(v.args)
+		if r != v.result || code != v.code {
+			t.Errorf("%v: got %q %v, expected %q %v", i, r, code, v.result, v.code)
+		}
+	}
+}
+
+func TestEntryWidgetProxy(t *testing.T) {
+	needTk(t)
+	entry := NewEntryWidgetProxy(Entry())
+
+	defer Destroy(entry.EntryWidget)
+
+	entry.Register("index", func(args []string) (string, error) { return "42", nil })
+	entry.Register("insert", func(args []string) (string, error) { return "", errors.New("read only") })
+	if g, e := evalErr(fmt.Sprintf("%s index end", entry)), "42"; g != e {
+		t.Errorf("index: got %q, expected %q", g, e)
+	}
+
+	if g, e := evalErr(fmt.Sprintf("list [catch {%s insert end x} msg] $msg", entry)), "1 {read only}"; g != e {
+		t.Errorf("insert: got %q, expected %q", g, e)
+	}
+
+	entry.Close()
+	if g, e := evalErr(fmt.Sprintf("%[1]s insert end x; %[1]s get", entry)), "x"; g != e {
+		t.Errorf("after Close: got %q, expected %q", g, e)
+	}
+}